@@ -1,49 +1,181 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // agar APP_TIMEZONE tetap bisa dimuat di image tanpa paket tzdata
 
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
 	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/database"
 	// Import package handlers kita
 	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/handlers"
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/storage"
+)
+
+// Metadata build, diisi saat kompilasi, mis.:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
 )
 
 func main() {
-	dbPool := database.Connect()
+	cfg := config.Load()
+	if err := handlers.ValidateDefaultAppointmentStatus(cfg.DefaultAppointmentStatus); err != nil {
+		log.Fatalf("Konfigurasi tidak valid: %s\n", err)
+	}
+	handlers.SetIdentifierMasking(cfg.LogMaskIdentifiers)
+	handlers.SetDisplayLocation(cfg.Location)
+
+	dbPool := database.Connect(cfg)
 	defer dbPool.Close()
 
+	// Data contoh hanya untuk development lokal, tidak pernah di production.
+	if cfg.SeedData && cfg.AppEnv != "production" && !cfg.ReadOnly {
+		if err := database.Seed(context.Background(), dbPool); err != nil {
+			log.Fatalf("Gagal mengisi data contoh: %s\n", err)
+		}
+		log.Println("Data contoh berhasil dimuat")
+	}
+
+	doctorCache := handlers.NewDoctorCache(cfg.DoctorCacheTTL)
+
+	documentStore, err := storage.NewLocalStorage(cfg.DocumentStorageDir)
+	if err != nil {
+		log.Fatalf("Gagal menyiapkan folder dokumen: %s\n", err)
+	}
+
+	maintenance := handlers.NewMaintenanceMode(cfg.MaintenanceMode, cfg.MaintenanceRetryAfter)
+
 	router := http.NewServeMux()
 
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Selamat Datang di API Pasien v1"))
-	})
+	router.HandleFunc("/", handlers.RootHandler(cfg))
+	router.HandleFunc("GET /version", handlers.VersionHandler(handlers.BuildInfo{Version: version, Commit: commit, BuildTime: buildTime}))
+	router.Handle("GET /debug/vars", expvar.Handler())
+	router.HandleFunc("GET /healthz", handlers.LivenessHandler())
+	router.HandleFunc("GET /livez", handlers.LivenessHandler())
+	router.HandleFunc("GET /readyz", handlers.ReadinessHandler(dbPool))
+	router.HandleFunc("GET /admin/maintenance", handlers.RequireAdmin(cfg, handlers.GetMaintenanceHandler(maintenance)))
+	router.HandleFunc("PUT /admin/maintenance", handlers.RequireAdmin(cfg, handlers.RequireJSON(handlers.SetMaintenanceHandler(maintenance))))
 
 	// --- Endpoints Pasien ---
-	router.HandleFunc("POST /patients", handlers.CreatePatientHandler(dbPool))
+	router.HandleFunc("POST /patients", handlers.RequireJSON(handlers.CreatePatientHandler(dbPool, cfg, handlers.NumericKTPValidator{})))
+	router.HandleFunc("GET /patients/today", handlers.GetPatientsTodayHandler(dbPool, cfg))
+	router.HandleFunc("GET /patients/by-ktp", handlers.GetPatientByKTPHandler(dbPool, handlers.NumericKTPValidator{}))
 	router.HandleFunc("GET /patients/{id}", handlers.GetPatientByIDHandler(dbPool))
+	router.HandleFunc("PATCH /patients/{id}", handlers.RequireJSON(handlers.PatchPatientHandler(dbPool, cfg, handlers.NumericKTPValidator{})))
+	router.HandleFunc("GET /patients/{id}/profile", handlers.GetPatientProfileHandler(dbPool))
+	router.HandleFunc("PATCH /patients/{id}/activate", handlers.SetPatientActiveHandler(dbPool, true))
+	router.HandleFunc("PATCH /patients/{id}/deactivate", handlers.SetPatientActiveHandler(dbPool, false))
+	router.HandleFunc("POST /patients/{id}/documents", handlers.UploadPatientDocumentHandler(dbPool, documentStore, cfg))
+	router.HandleFunc("GET /patients/{id}/documents", handlers.GetPatientDocumentsHandler(dbPool, cfg))
 
 	// --- Endpoints Dokter ---
-	router.HandleFunc("GET /doctors", handlers.GetAllDoctorsHandler(dbPool))
-	router.HandleFunc("POST /doctors", handlers.CreateDoctorHandler(dbPool))
+	router.HandleFunc("GET /doctors", handlers.GetAllDoctorsHandler(dbPool, doctorCache, cfg))
+	router.HandleFunc("GET /doctors/by-nik", handlers.GetDoctorByNIKHandler(dbPool))
+	router.HandleFunc("GET /doctors/working", handlers.GetDoctorsWorkingAtHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/available-today", handlers.GetDoctorsAvailableTodayHandler(dbPool, cfg))
+	router.HandleFunc("POST /doctors", handlers.RequireJSON(handlers.CreateDoctorHandler(dbPool, doctorCache, cfg)))
+	router.HandleFunc("PUT /doctors/{id}", handlers.RequireJSON(handlers.UpdateDoctorHandler(dbPool, doctorCache, cfg)))
 	// --- Endpoints Jadwal Kerja Dokter ---
-	router.HandleFunc("POST /doctors/{id}/schedules", handlers.AddDoctorScheduleHandler(dbPool))
+	router.HandleFunc("POST /doctors/{id}/schedules", handlers.RequireJSON(handlers.AddDoctorScheduleHandler(dbPool, cfg)))
 	router.HandleFunc("GET /doctors/{id}/schedules", handlers.GetDoctorSchedulesHandler(dbPool))
-	router.HandleFunc("POST /doctors/{id}/timeoff", handlers.AddDoctorTimeOffHandler(dbPool))
+	router.HandleFunc("GET /doctors/{id}/schedules/{day}", handlers.GetDoctorScheduleByDayHandler(dbPool))
+	router.HandleFunc("POST /doctors/{id}/schedules/{day}/preview", handlers.RequireJSON(handlers.PreviewScheduleChangeHandler(dbPool, cfg)))
+	router.HandleFunc("GET /doctors/{id}/slot-check", handlers.SlotCheckHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/{id}/conflicts", handlers.RequireAdmin(cfg, handlers.GetDoctorConflictsHandler(dbPool, cfg)))
+	router.HandleFunc("GET /doctors/{id}/capacity", handlers.GetDoctorCapacityHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/{id}/calendar", handlers.GetDoctorCalendarHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/{id}/patients", handlers.GetDoctorPatientsHandler(dbPool, cfg))
+	router.HandleFunc("POST /doctors/{id}/transfer", handlers.RequireJSON(handlers.TransferDoctorAppointmentsHandler(dbPool, cfg)))
+	router.HandleFunc("POST /doctors/{id}/timeoff", handlers.RequireJSON(handlers.AddDoctorTimeOffHandler(dbPool, cfg)))
+	router.HandleFunc("GET /doctors/{id}/timeoff", handlers.GetDoctorTimeOffHandler(dbPool, cfg))
+	router.HandleFunc("GET /schedules", handlers.GetSchedulesByDayHandler(dbPool))
+	router.HandleFunc("GET /booking/options", handlers.GetBookingOptionsHandler(dbPool, cfg))
+	router.HandleFunc("GET /holidays", handlers.RequireAdmin(cfg, handlers.GetHolidaysHandler(dbPool, cfg)))
+	router.HandleFunc("POST /holidays", handlers.RequireAdmin(cfg, handlers.RequireJSON(handlers.CreateHolidayHandler(dbPool))))
+	router.HandleFunc("GET /reports/doctor-appointments", handlers.RequireAdmin(cfg, handlers.GetDoctorAppointmentReportHandler(dbPool, cfg)))
+	router.HandleFunc("GET /reports/peak-hours", handlers.RequireAdmin(cfg, handlers.GetPeakHoursReportHandler(dbPool, cfg)))
+	router.HandleFunc("GET /doctors/{id}/appointments/export", handlers.ExportDoctorAppointmentsHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/{id}/appointments/current", handlers.GetCurrentDoctorAppointmentHandler(dbPool, cfg))
+	router.HandleFunc("GET /doctors/{id}/appointments/by-date", handlers.GetDoctorAppointmentsByDateHandler(dbPool, cfg))
+
+	// --- Endpoint Walk-in (pasien + janji temu sekaligus) ---
+	router.HandleFunc("POST /walk-in", handlers.RequireJSON(handlers.WalkInHandler(dbPool, cfg, handlers.NumericKTPValidator{})))
 
 	// --- Endpoint Janji Temu ---
-	router.HandleFunc("POST /appointments", handlers.CreateAppointmentHandler(dbPool))
-	router.HandleFunc("GET /patients/{id}/appointments", handlers.GetAppointmentsByPatientIDHandler(dbPool))
-	router.HandleFunc("PATCH /appointments/{id}", handlers.RescheduleAppointmentHandler(dbPool))
+	router.HandleFunc("GET /appointments", handlers.GetAllAppointmentsHandler(dbPool, cfg))
+	router.HandleFunc("GET /appointments/counts", handlers.GetAppointmentCountsHandler(dbPool, cfg))
+	router.HandleFunc("GET /appointments/load", handlers.GetAppointmentLoadHandler(dbPool, cfg))
+	router.HandleFunc("GET /appointments/{file}", handlers.GetAppointmentICSHandler(dbPool, cfg))
+	router.HandleFunc("POST /appointments", handlers.RequireJSON(handlers.CreateAppointmentHandler(dbPool, cfg)))
+	router.HandleFunc("POST /appointments/import", handlers.RequireAdmin(cfg, handlers.ImportAppointmentsHandler(dbPool, cfg)))
+	router.HandleFunc("POST /appointments/validate-batch", handlers.RequireJSON(handlers.ValidateAppointmentBatchHandler(dbPool, cfg)))
+	router.HandleFunc("POST /appointments/hold", handlers.RequireJSON(handlers.HoldAppointmentHandler(dbPool, cfg)))
+	router.HandleFunc("POST /appointments/{id}/confirm", handlers.ConfirmAppointmentHandler(dbPool))
+	router.HandleFunc("POST /appointments/{id}/attendance", handlers.ConfirmAttendanceHandler(dbPool))
+	router.HandleFunc("GET /appointments/unconfirmed", handlers.GetUnconfirmedAppointmentsHandler(dbPool, cfg))
+	router.HandleFunc("GET /appointments/reminders/pending", handlers.GetPendingRemindersHandler(dbPool, cfg))
+	router.HandleFunc("POST /appointments/{id}/reminder-sent", handlers.MarkReminderSentHandler(dbPool))
+	router.HandleFunc("GET /patients/{id}/appointments", handlers.GetAppointmentsByPatientIDHandler(dbPool, cfg))
+	router.HandleFunc("GET /patients/{id}/appointments/next", handlers.GetNextAppointmentHandler(dbPool))
+	router.HandleFunc("POST /patients/{id}/appointments/cancel-all", handlers.CancelAllPatientAppointmentsHandler(dbPool))
+	router.HandleFunc("PATCH /appointments/{id}", handlers.RequireJSON(handlers.RescheduleAppointmentHandler(dbPool, cfg)))
+	router.HandleFunc("POST /appointments/{id}/reschedule-next", handlers.RescheduleToNextSlotHandler(dbPool, cfg))
+	router.HandleFunc("POST /appointments/{id}/reactivate", handlers.ReactivateAppointmentHandler(dbPool, cfg))
+	router.HandleFunc("PATCH /appointments/{id}/reason", handlers.RequireJSON(handlers.UpdateAppointmentReasonHandler(dbPool)))
+	router.HandleFunc("GET /appointments/{id}/summary", handlers.GetAppointmentSummaryHandler(dbPool, cfg))
+	router.HandleFunc("GET /appointments/{id}/staff-notes", handlers.RequireAdmin(cfg, handlers.GetAppointmentStaffNotesHandler(dbPool)))
+	router.HandleFunc("PATCH /appointments/{id}/staff-notes", handlers.RequireAdmin(cfg, handlers.RequireJSON(handlers.UpdateAppointmentStaffNotesHandler(dbPool))))
+	router.HandleFunc("PATCH /appointments/{id}/check-in", handlers.CheckInAppointmentHandler(dbPool, cfg))
+
+	// ctx dibatalkan saat aplikasi menerima sinyal berhenti (Ctrl+C / SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Sweeper mengubah data, jadi tidak dijalankan dalam mode baca saja
+	var wg sync.WaitGroup
+	if !cfg.ReadOnly {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handlers.RunSweeper(ctx, dbPool, cfg)
+		}()
+	} else {
+		log.Println("Mode baca saja aktif: request yang mengubah data akan ditolak")
+	}
 
 	port := ":8080"
 	server := &http.Server{
 		Addr:    port,
-		Handler: router,
+		Handler: handlers.LogRequests(cfg.LogSampleRate, handlers.CORS(cfg.CORSAllowedOrigins, handlers.ReadOnly(cfg.ReadOnly, handlers.MaintenanceMiddleware(maintenance, handlers.LimitDBAcquire(cfg.DBAcquireTimeout, router))))),
 	}
 
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Gagal menghentikan server dengan rapi: %s", err)
+		}
+	}()
+
 	log.Printf("Server dimulai di port %s", port)
-	if err := server.ListenAndServe(); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Gagal memulai server: %s\n", err)
 	}
+
+	// Tunggu pekerjaan latar belakang selesai sebelum koneksi database ditutup
+	wg.Wait()
+	log.Println("Server berhenti")
 }
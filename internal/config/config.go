@@ -0,0 +1,298 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config menampung semua pengaturan aplikasi yang dibaca dari environment variable.
+// Setiap field punya nilai default sehingga aplikasi tetap bisa jalan tanpa konfigurasi.
+type Config struct {
+	// DBMaxConns dan DBMinConns mengatur jumlah koneksi di connection pool,
+	// DBMaxConnIdleTime dan DBMaxConnLifetime mengatur umur koneksi.
+	// Nilai 0 berarti memakai default pgxpool.
+	DBMaxConns        int
+	DBMinConns        int
+	DBMaxConnIdleTime time.Duration
+	DBMaxConnLifetime time.Duration
+	// DBStatementTimeout adalah batas waktu default setiap query (statement_timeout
+	// PostgreSQL). 0 berarti tanpa batas.
+	DBStatementTimeout time.Duration
+	// DBAcquireTimeout adalah batas waktu sebuah request memakai database,
+	// termasuk menunggu koneksi bebas saat semua koneksi pool sedang dipakai
+	// (DB_ACQUIRE_TIMEOUT). Setelah lewat, request dijawab 503 alih-alih
+	// menggantung. 0 berarti tanpa batas.
+	DBAcquireTimeout time.Duration
+
+	// AppEnv adalah nama environment aplikasi (development, staging, production).
+	AppEnv string
+	// SeedData mengaktifkan pengisian data contoh saat aplikasi dimulai.
+	// Selalu diabaikan jika AppEnv adalah production.
+	SeedData bool
+
+	// Location adalah zona waktu aplikasi (APP_TIMEZONE). Semua timestamp
+	// disimpan di database dalam UTC, lalu ditampilkan dan ditafsirkan
+	// (hari kerja, jam praktik, tanggal libur) menurut zona waktu ini.
+	Location *time.Location
+
+	// APIName dan APIVersion ditampilkan di endpoint root agar
+	// environment yang berbeda (dev, staging, prod) mudah dibedakan.
+	APIName    string
+	APIVersion string
+
+	// ClinicName, ClinicAddress, dan ClinicPhone dicantumkan di ringkasan
+	// janji temu untuk pasien (CLINIC_NAME, CLINIC_ADDRESS, CLINIC_PHONE).
+	ClinicName    string
+	ClinicAddress string
+	ClinicPhone   string
+
+	// LogMaskIdentifiers menyamarkan KTP, NIK, dan ID pasien/dokter di log
+	// (LOG_MASK_IDENTIFIERS). Matikan hanya untuk debugging.
+	LogMaskIdentifiers bool
+	// LogSampleRate membuat request sukses hanya dicatat 1 dari setiap N request
+	// (LOG_SAMPLE_RATE). Request yang gagal selalu dicatat. 1 berarti catat semua.
+	LogSampleRate int
+
+	// CORSAllowedOrigins adalah daftar origin (dipisahkan koma) yang boleh
+	// mengakses API dari browser. "*" mengizinkan semua origin; kosong berarti
+	// header CORS tidak dikirim sama sekali.
+	CORSAllowedOrigins []string
+
+	// ReadOnly menjalankan API dalam mode baca saja (READ_ONLY), mis. saat
+	// failover ke replica database. Berbeda dengan MaintenanceMode, mode ini
+	// hanya bisa diubah lewat environment, tidak mengirim Retry-After, dan
+	// juga menghentikan sweeper serta pengisian data contoh.
+	ReadOnly bool
+
+	// MaintenanceMode menolak semua request yang mengubah data dengan 503 sejak
+	// aplikasi dimulai (MAINTENANCE_MODE). Admin bisa mengubahnya saat aplikasi
+	// berjalan lewat PUT /admin/maintenance. MaintenanceRetryAfter dikirim di
+	// header Retry-After selama maintenance.
+	MaintenanceMode       bool
+	MaintenanceRetryAfter time.Duration
+
+	// AdminAPIKey adalah kunci yang harus dikirim lewat header X-Admin-Key
+	// agar sebuah request diperlakukan sebagai admin. Jika kosong, tidak ada
+	// request yang dianggap admin.
+	AdminAPIKey string
+
+	// PatientUniqueKTPAndDOB menganggap pasien sama hanya jika nomor KTP dan
+	// tanggal lahirnya sama (PATIENT_UNIQUE_KTP_DOB). Constraint di database
+	// disesuaikan lewat migrations/optional/patients_ktp_dob_identity.sql.
+	PatientUniqueKTPAndDOB bool
+
+	// DoctorNIKRequired mewajibkan NIK saat membuat atau mengubah dokter
+	// (DOCTOR_NIK_REQUIRED). Matikan untuk klinik yang memakai ID internal;
+	// NIK yang tetap diisi masih harus 10 digit angka.
+	DoctorNIKRequired bool
+
+	// MinRescheduleNotice adalah jarak waktu minimum sebelum janji temu dimulai
+	// agar janji temu tersebut masih boleh dijadwalkan ulang. 0 berarti tanpa batas.
+	MinRescheduleNotice time.Duration
+	// DefaultAppointmentStatus adalah status awal janji temu yang baru dibuat
+	// (DEFAULT_APPOINTMENT_STATUS), diisi eksplisit oleh aplikasi saat INSERT.
+	DefaultAppointmentStatus string
+	// ReschedulableStatuses adalah status janji temu yang masih boleh
+	// dijadwalkan ulang (RESCHEDULABLE_STATUSES, dipisahkan koma).
+	ReschedulableStatuses []string
+	// RescheduleSearchDays adalah berapa hari ke depan slot kosong dicari saat
+	// janji temu dipindahkan otomatis ke slot berikutnya.
+	RescheduleSearchDays int
+
+	// SlotDuration adalah panjang satu slot janji temu saat menghitung
+	// ketersediaan jadwal dokter.
+	SlotDuration time.Duration
+	// AllowOvernightSchedules mengizinkan jadwal dokter yang melewati tengah
+	// malam (mis. 22:00 sampai 06:00 keesokan harinya). Nonaktif secara default
+	// agar klinik siang hari tetap menolak endTime sebelum startTime.
+	AllowOvernightSchedules bool
+	// HoldTTL adalah lama sebuah slot di-hold sebelum harus dikonfirmasi.
+	HoldTTL time.Duration
+	// ReminderLeadTime adalah jarak default pengiriman pengingat sebelum janji
+	// temu, untuk dokter yang tidak mengatur reminder_lead_time sendiri.
+	ReminderLeadTime time.Duration
+	// ConfirmationWindow adalah berapa lama sebelum janji temu pasien harus
+	// sudah mengonfirmasi kehadirannya (CONFIRMATION_WINDOW), dipakai sebagai
+	// default ?within= pada GET /appointments/unconfirmed.
+	ConfirmationWindow time.Duration
+	// MaxAppointmentsPerPatientPerDay membatasi jumlah janji temu aktif seorang
+	// pasien pada satu tanggal, di semua dokter
+	// (MAX_APPOINTMENTS_PER_PATIENT_PER_DAY). 0 (default) berarti tanpa batas.
+	MaxAppointmentsPerPatientPerDay int
+
+	// SweepInterval adalah jeda antar putaran sweeper latar belakang (0 = nonaktif).
+	SweepInterval time.Duration
+	// SweepMarkPastDue membuat sweeper menandai janji temu yang terlewat tanpa check-in.
+	SweepMarkPastDue bool
+
+	// DoctorCacheTTL adalah lama daftar dokter disimpan di cache memori.
+	// 0 berarti cache dinonaktifkan.
+	DoctorCacheTTL time.Duration
+
+	// AuditSpecialtyChanges mencatat setiap perubahan spesialisasi dokter ke
+	// tabel doctor_specialty_audit dan menerbitkan event doctor.specialty_changed.
+	AuditSpecialtyChanges bool
+
+	// DocumentStorageDir adalah folder tempat file dokumen pasien disimpan.
+	DocumentStorageDir string
+	// DocumentMaxSize adalah ukuran maksimum satu file dokumen (byte).
+	DocumentMaxSize int64
+	// DocumentAllowedTypes adalah daftar content type yang boleh diunggah.
+	DocumentAllowedTypes []string
+
+	// DefaultPageSize dan MaxPageSize mengatur ?limit= pada endpoint daftar.
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// Load membaca konfigurasi dari environment variable.
+func Load() *Config {
+	return &Config{
+		DBMaxConns:         getEnvInt("DB_MAX_CONNS", 0),
+		DBMinConns:         getEnvInt("DB_MIN_CONNS", 0),
+		DBMaxConnIdleTime:  getEnvDuration("DB_MAX_CONN_IDLE_TIME", 0),
+		DBMaxConnLifetime:  getEnvDuration("DB_MAX_CONN_LIFETIME", 0),
+		DBStatementTimeout: getEnvDuration("DB_STATEMENT_TIMEOUT", 10*time.Second),
+		DBAcquireTimeout:   getEnvDuration("DB_ACQUIRE_TIMEOUT", 3*time.Second),
+
+		AppEnv:   getEnv("APP_ENV", "development"),
+		SeedData: getEnvBool("SEED_DATA", false),
+
+		Location: getEnvLocation("APP_TIMEZONE", "Asia/Jakarta"),
+
+		APIName:    getEnv("API_NAME", "API Pasien"),
+		APIVersion: getEnv("API_VERSION", "v1"),
+
+		ClinicName:    getEnv("CLINIC_NAME", "Klinik"),
+		ClinicAddress: getEnv("CLINIC_ADDRESS", ""),
+		ClinicPhone:   getEnv("CLINIC_PHONE", ""),
+
+		LogMaskIdentifiers: getEnvBool("LOG_MASK_IDENTIFIERS", true),
+		LogSampleRate:      getEnvInt("LOG_SAMPLE_RATE", 1),
+
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", nil),
+
+		ReadOnly: getEnvBool("READ_ONLY", false),
+
+		MaintenanceMode:       getEnvBool("MAINTENANCE_MODE", false),
+		MaintenanceRetryAfter: getEnvDuration("MAINTENANCE_RETRY_AFTER", 5*time.Minute),
+
+		AdminAPIKey: os.Getenv("ADMIN_API_KEY"),
+
+		PatientUniqueKTPAndDOB: getEnvBool("PATIENT_UNIQUE_KTP_DOB", false),
+
+		DoctorNIKRequired: getEnvBool("DOCTOR_NIK_REQUIRED", true),
+
+		MinRescheduleNotice:      getEnvDuration("MIN_RESCHEDULE_NOTICE", 0),
+		DefaultAppointmentStatus: strings.ToUpper(getEnv("DEFAULT_APPOINTMENT_STATUS", "CONFIRMED")),
+		ReschedulableStatuses:    getEnvList("RESCHEDULABLE_STATUSES", []string{"CONFIRMED", "RESCHEDULED"}),
+		RescheduleSearchDays:     getEnvInt("RESCHEDULE_SEARCH_DAYS", 14),
+
+		SlotDuration: getEnvDuration("SLOT_DURATION", 30*time.Minute),
+		HoldTTL:      getEnvDuration("HOLD_TTL", 5*time.Minute),
+
+		ReminderLeadTime:   getEnvDuration("REMINDER_LEAD_TIME", 24*time.Hour),
+		ConfirmationWindow: getEnvDuration("CONFIRMATION_WINDOW", 24*time.Hour),
+
+		AllowOvernightSchedules: getEnvBool("ALLOW_OVERNIGHT_SCHEDULES", false),
+
+		MaxAppointmentsPerPatientPerDay: getEnvInt("MAX_APPOINTMENTS_PER_PATIENT_PER_DAY", 0),
+
+		SweepInterval:    getEnvDuration("SWEEP_INTERVAL", time.Minute),
+		SweepMarkPastDue: getEnvBool("SWEEP_MARK_PAST_DUE", false),
+
+		DoctorCacheTTL: getEnvDuration("DOCTOR_CACHE_TTL", 60*time.Second),
+
+		AuditSpecialtyChanges: getEnvBool("AUDIT_SPECIALTY_CHANGES", false),
+
+		DocumentStorageDir:   getEnv("DOCUMENT_STORAGE_DIR", "./uploads"),
+		DocumentMaxSize:      int64(getEnvInt("DOCUMENT_MAX_SIZE", 5<<20)),
+		DocumentAllowedTypes: getEnvList("DOCUMENT_ALLOWED_TYPES", []string{"image/jpeg", "image/png", "application/pdf"}),
+
+		DefaultPageSize: getEnvInt("DEFAULT_PAGE_SIZE", 20),
+		MaxPageSize:     getEnvInt("MAX_PAGE_SIZE", 100),
+	}
+}
+
+// getEnv mengembalikan nilai environment variable, atau fallback jika kosong.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration membaca environment variable berformat durasi Go (mis. "2h", "30m").
+// Nilai yang tidak valid dicatat di log lalu diganti dengan fallback.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Nilai %s tidak valid (%q), memakai default %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getEnvInt membaca environment variable berupa bilangan bulat positif.
+// Nilai yang tidak valid dicatat di log lalu diganti dengan fallback.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Nilai %s tidak valid (%q), memakai default %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvList membaca environment variable berisi daftar yang dipisahkan koma.
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvBool membaca environment variable berupa boolean ("true", "1", dst).
+// Nilai yang tidak valid dicatat di log lalu diganti dengan fallback.
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Nilai %s tidak valid (%q), memakai default %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+// getEnvLocation memuat zona waktu IANA (mis. "Asia/Jakarta") dari environment.
+// Nama zona yang tidak dikenal dicatat di log lalu diganti dengan UTC.
+func getEnvLocation(key, fallback string) *time.Location {
+	name := getEnv(key, fallback)
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Zona waktu %s tidak valid (%q), memakai UTC", key, name)
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadDatabasePoolSettings(t *testing.T) {
+	t.Setenv("DB_MAX_CONNS", "25")
+	t.Setenv("DB_MIN_CONNS", "5")
+	t.Setenv("DB_MAX_CONN_IDLE_TIME", "10m")
+	t.Setenv("DB_MAX_CONN_LIFETIME", "1h")
+	t.Setenv("DB_STATEMENT_TIMEOUT", "30s")
+
+	cfg := Load()
+	if cfg.DBMaxConns != 25 || cfg.DBMinConns != 5 {
+		t.Errorf("DBMaxConns/DBMinConns = %d/%d, want 25/5", cfg.DBMaxConns, cfg.DBMinConns)
+	}
+	if cfg.DBMaxConnIdleTime != 10*time.Minute || cfg.DBMaxConnLifetime != time.Hour {
+		t.Errorf("DBMaxConnIdleTime/DBMaxConnLifetime = %s/%s, want 10m/1h", cfg.DBMaxConnIdleTime, cfg.DBMaxConnLifetime)
+	}
+	if cfg.DBStatementTimeout != 30*time.Second {
+		t.Errorf("DBStatementTimeout = %s, want 30s", cfg.DBStatementTimeout)
+	}
+}
+
+func TestLoadDatabasePoolDefaults(t *testing.T) {
+	t.Setenv("DB_MAX_CONNS", "")
+	t.Setenv("DB_MIN_CONNS", "banyak") // tidak valid, memakai default
+	t.Setenv("DB_STATEMENT_TIMEOUT", "")
+
+	cfg := Load()
+	if cfg.DBMaxConns != 0 || cfg.DBMinConns != 0 {
+		t.Errorf("DBMaxConns/DBMinConns = %d/%d, want 0/0", cfg.DBMaxConns, cfg.DBMinConns)
+	}
+	if cfg.DBStatementTimeout != 10*time.Second {
+		t.Errorf("DBStatementTimeout = %s, want 10s", cfg.DBStatementTimeout)
+	}
+}
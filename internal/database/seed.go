@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Seed mengisi database dengan sedikit data contoh (dokter, jadwal, pasien,
+// dan janji temu) untuk development lokal. Aman dijalankan berulang kali:
+// data yang sudah ada tidak akan diduplikasi.
+// Jangan panggil fungsi ini di production.
+func Seed(ctx context.Context, pool *pgxpool.Pool) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// 1. Dokter
+	doctors := []struct{ nik, name, specialty string }{
+		{"1000000001", "dr. Andi Wijaya", "Umum"},
+		{"1000000002", "dr. Sari Lestari, Sp.A", "Anak"},
+		{"1000000003", "dr. Budi Santoso, Sp.JP", "Jantung"},
+	}
+	doctorIDs := make([]int, len(doctors))
+	for i, d := range doctors {
+		_, err := tx.Exec(ctx, `INSERT INTO doctors (nik, name, specialty) VALUES ($1, $2, $3)
+                  ON CONFLICT (nik) DO NOTHING`, d.nik, d.name, d.specialty)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, "SELECT id FROM doctors WHERE nik = $1", d.nik).Scan(&doctorIDs[i]); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO doctor_specialties (doctor_id, specialty)
+                  SELECT id, specialty FROM doctors WHERE id = $1
+                  ON CONFLICT DO NOTHING`, doctorIDs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	// 2. Jadwal kerja Senin–Jumat, 08:00–16:00
+	for _, doctorID := range doctorIDs {
+		for day := 1; day <= 5; day++ {
+			_, err := tx.Exec(ctx, `INSERT INTO doctor_schedules (doctor_id, day_of_week, start_time, end_time)
+                      VALUES ($1, $2, '08:00:00', '16:00:00')
+                      ON CONFLICT (doctor_id, day_of_week) DO NOTHING`, doctorID, day)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3. Pasien
+	patients := []struct{ ktp, name, dob string }{
+		{"3171000000000001", "Rina Marlina", "1990-04-12"},
+		{"3171000000000002", "Agus Setiawan", "1985-11-03"},
+		{"3171000000000003", "Dewi Anggraini", "2015-07-21"},
+	}
+	patientIDs := make([]int, len(patients))
+	for i, p := range patients {
+		_, err := tx.Exec(ctx, `INSERT INTO patients (ktp_number, full_name, date_of_birth) VALUES ($1, $2, $3)
+                  ON CONFLICT DO NOTHING`, p.ktp, p.name, p.dob)
+		if err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, "SELECT id FROM patients WHERE ktp_number = $1 AND date_of_birth = $2", p.ktp, p.dob).Scan(&patientIDs[i]); err != nil {
+			return err
+		}
+	}
+
+	// 4. Satu janji temu per pasien pada Senin berikutnya, hanya jika pasien
+	// tersebut belum punya janji temu dengan dokter yang sama.
+	monday := nextMonday(time.Now())
+	for i, patientID := range patientIDs {
+		doctorID := doctorIDs[i%len(doctorIDs)]
+		apptDate := monday.Add(time.Duration(9+i) * time.Hour)
+		_, err := tx.Exec(ctx, `INSERT INTO appointments (patient_id, doctor_id, appointment_date)
+                  SELECT $1, $2, $3
+                  WHERE NOT EXISTS (SELECT 1 FROM appointments WHERE patient_id = $1 AND doctor_id = $2)`,
+			patientID, doctorID, apptDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// nextMonday mengembalikan tengah malam hari Senin setelah t.
+func nextMonday(t time.Time) time.Time {
+	days := (8 - int(t.Weekday())) % 7
+	if days == 0 {
+		days = 7
+	}
+	d := t.AddDate(0, 0, days)
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestSeedIsIdempotent(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL tidak diisi, test database dilewati")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("Gagal membuat pool: %v", err)
+	}
+	defer pool.Close()
+
+	// Jumlah baris data contoh, dikenali dari NIK dan KTP-nya
+	counts := map[string]string{
+		"doctors":      "SELECT COUNT(*) FROM doctors WHERE nik LIKE '100000000_'",
+		"specialties":  "SELECT COUNT(*) FROM doctor_specialties ds JOIN doctors d ON ds.doctor_id = d.id WHERE d.nik LIKE '100000000_'",
+		"schedules":    "SELECT COUNT(*) FROM doctor_schedules s JOIN doctors d ON s.doctor_id = d.id WHERE d.nik LIKE '100000000_'",
+		"patients":     "SELECT COUNT(*) FROM patients WHERE ktp_number LIKE '317100000000000_'",
+		"appointments": "SELECT COUNT(*) FROM appointments a JOIN patients p ON a.patient_id = p.id WHERE p.ktp_number LIKE '317100000000000_'",
+	}
+	snapshot := func() map[string]int {
+		got := map[string]int{}
+		for name, query := range counts {
+			var n int
+			if err := pool.QueryRow(ctx, query).Scan(&n); err != nil {
+				t.Fatalf("Gagal menghitung %s: %v", name, err)
+			}
+			got[name] = n
+		}
+		return got
+	}
+
+	if err := Seed(ctx, pool); err != nil {
+		t.Fatalf("Seed pertama: %v", err)
+	}
+	first := snapshot()
+	want := map[string]int{"doctors": 3, "specialties": 3, "schedules": 15, "patients": 3, "appointments": 3}
+	for name, n := range want {
+		if first[name] < n {
+			t.Errorf("%s setelah Seed = %d, want minimal %d", name, first[name], n)
+		}
+	}
+
+	if err := Seed(ctx, pool); err != nil {
+		t.Fatalf("Seed kedua: %v", err)
+	}
+	for name, n := range snapshot() {
+		if n != first[name] {
+			t.Errorf("%s berubah dari %d menjadi %d setelah Seed kedua", name, first[name], n)
+		}
+	}
+}
+
+func TestNextMonday(t *testing.T) {
+	tests := []struct{ from, want string }{
+		{from: "2025-01-01T15:04:00Z", want: "2025-01-06T00:00:00Z"}, // Rabu
+		{from: "2025-01-05T23:00:00Z", want: "2025-01-06T00:00:00Z"}, // Minggu
+		{from: "2025-01-06T08:00:00Z", want: "2025-01-13T00:00:00Z"}, // Senin
+	}
+	for _, tt := range tests {
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		if got := nextMonday(from).Format(time.RFC3339); got != tt.want {
+			t.Errorf("nextMonday(%s) = %s, want %s", tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,886 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Status janji temu.
+const (
+	StatusConfirmed   = "CONFIRMED"
+	StatusRescheduled = "RESCHEDULED"
+	StatusCheckedIn   = "CHECKED_IN"
+	StatusCancelled   = "CANCELLED"
+	StatusHeld        = "HELD"
+)
+
+// Prioritas (urgensi) janji temu, dari yang paling rendah.
+const (
+	PriorityRoutine   = "ROUTINE"
+	PriorityUrgent    = "URGENT"
+	PriorityEmergency = "EMERGENCY"
+)
+
+// isKnownPriority melaporkan apakah p adalah salah satu prioritas janji temu yang dikenal.
+func isKnownPriority(p string) bool {
+	switch p {
+	case PriorityRoutine, PriorityUrgent, PriorityEmergency:
+		return true
+	}
+	return false
+}
+
+// priorityRankSQL mengurutkan janji temu dari prioritas tertinggi (EMERGENCY) ke terendah.
+const priorityRankSQL = "CASE priority WHEN 'EMERGENCY' THEN 0 WHEN 'URGENT' THEN 1 ELSE 2 END"
+
+// isKnownStatus melaporkan apakah s adalah salah satu status janji temu yang dikenal.
+func isKnownStatus(s string) bool {
+	switch s {
+	case StatusConfirmed, StatusRescheduled, StatusCheckedIn, StatusCancelled, StatusHeld, StatusNeedsResolution:
+		return true
+	}
+	return false
+}
+
+// ValidateDefaultAppointmentStatus memastikan status awal janji temu dari
+// konfigurasi (DEFAULT_APPOINTMENT_STATUS) adalah status yang dikenal. HELD
+// tidak diizinkan karena hold membutuhkan hold_expires_at. Dipanggil sekali
+// saat aplikasi dimulai agar salah ketik tidak menghasilkan janji temu dengan
+// status yang tidak dikenali query lain.
+func ValidateDefaultAppointmentStatus(status string) error {
+	if !isKnownStatus(status) || status == StatusHeld {
+		return fmt.Errorf("DEFAULT_APPOINTMENT_STATUS %q bukan status awal janji temu yang valid", status)
+	}
+	return nil
+}
+
+// activeAppointmentCondition adalah kondisi SQL untuk janji temu yang masih
+// menempati slot: belum dibatalkan dan bukan hold yang sudah kedaluwarsa.
+const activeAppointmentCondition = "status <> 'CANCELLED' AND NOT (status = 'HELD' AND hold_expires_at <= NOW())"
+
+// appointmentColumns adalah daftar kolom standar untuk dipindai ke struct Appointment
+// lewat scanAppointment. Urutannya harus sama dengan urutan Scan di bawah.
+const appointmentColumns = "id, patient_id, doctor_id, appointment_date, status, created_at, checked_in_at, hold_expires_at, reminder_sent_at, reason, created_by, confirmed_at, priority"
+
+// qualifiedAppointmentColumns mengembalikan appointmentColumns dengan prefix
+// alias tabel (mis. "a.id, a.patient_id, ..."), untuk query yang memakai JOIN.
+func qualifiedAppointmentColumns(alias string) string {
+	return qualifyColumns(alias, appointmentColumns)
+}
+
+// qualifyColumns menambahkan prefix alias tabel pada setiap kolom di cols.
+func qualifyColumns(alias, cols string) string {
+	list := strings.Split(cols, ", ")
+	for i, c := range list {
+		list[i] = alias + "." + c
+	}
+	return strings.Join(list, ", ")
+}
+
+// scanAppointment memindai satu baris hasil SELECT/RETURNING appointmentColumns.
+// Kolom tambahan (mis. hasil JOIN) bisa dipindai lewat extra, sesudah kolom standar.
+func scanAppointment(row pgx.Row, a *Appointment, extra ...any) error {
+	return row.Scan(append(appointmentScanDest(a), extra...)...)
+}
+
+// appointmentScanDest mengembalikan pointer field a sesuai urutan appointmentColumns.
+func appointmentScanDest(a *Appointment) []any {
+	return []any{&a.ID, &a.PatientID, &a.DoctorID, &a.AppointmentDate, &a.Status, &a.CreatedAt, &a.CheckedInAt, &a.HoldExpiresAt, &a.ReminderSentAt, &a.Reason, &a.CreatedBy, &a.ConfirmedAt, &a.Priority}
+}
+
+// GetAllAppointmentsHandler mengambil semua janji temu di klinik.
+// Filter opsional:
+//   - from / to: rentang tanggal janji temu (appointment_date)
+//   - createdFrom / createdTo: rentang tanggal janji temu dibuat (created_at)
+//   - createdBy: ID petugas yang membuat janji temu (header X-Actor-ID)
+//   - priority: ROUTINE, URGENT, atau EMERGENCY
+//
+// ?sort=priority mengurutkan dari prioritas tertinggi, lalu dari jadwal terbaru.
+// Semua tanggal memakai format YYYY-MM-DD, bersifat inklusif, dan dibaca
+// menurut zona waktu aplikasi.
+func GetAllAppointmentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := parsePagination(r, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 1. Baca & validasi filter tanggal dari query string
+		apptRange, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		createdRange, err := parseDateRange(r, "createdFrom", "createdTo", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		priority := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("priority")))
+		if priority != "" && !isKnownPriority(priority) {
+			http.Error(w, "priority harus ROUTINE, URGENT, atau EMERGENCY", http.StatusBadRequest)
+			return
+		}
+		orderBy := "appointment_date DESC, id DESC"
+		switch sort := r.URL.Query().Get("sort"); sort {
+		case "":
+		case "priority":
+			orderBy = priorityRankSQL + ", " + orderBy
+		default:
+			http.Error(w, "sort yang didukung saat ini hanya priority", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Susun query secara dinamis sesuai filter yang diberikan
+		var conditions []string
+		var args []any
+		addCondition := func(format string, value any) {
+			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf(format, len(args)))
+		}
+		if apptRange.From != nil {
+			addCondition("appointment_date >= $%d", *apptRange.From)
+		}
+		if apptRange.To != nil {
+			addCondition("appointment_date < $%d", apptRange.To.AddDate(0, 0, 1))
+		}
+		if createdRange.From != nil {
+			addCondition("created_at >= $%d", *createdRange.From)
+		}
+		if createdRange.To != nil {
+			addCondition("created_at < $%d", createdRange.To.AddDate(0, 0, 1))
+		}
+		if createdBy := strings.TrimSpace(r.URL.Query().Get("createdBy")); createdBy != "" {
+			addCondition("created_by = $%d", createdBy)
+		}
+		if priority != "" {
+			addCondition("priority = $%d", priority)
+		}
+
+		where := ""
+		if len(conditions) > 0 {
+			where = " WHERE " + strings.Join(conditions, " AND ")
+		}
+		countQuery := `SELECT COUNT(*) FROM appointments` + where
+		countArgs := args
+		query := `SELECT ` + appointmentColumns + ` FROM appointments` + where
+		args = append(args, limit, offset)
+		query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", orderBy, len(args)-1, len(args))
+
+		// 3. Looping melalui hasil dan masukkan ke dalam slice
+		var appointments []Appointment
+		var total int
+		err = withRetry(r.Context(), func() error {
+			appointments = nil
+			if err := dbpool.QueryRow(r.Context(), countQuery, countArgs...).Scan(&total); err != nil {
+				return err
+			}
+			rows, err := dbpool.Query(r.Context(), query, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var appt Appointment
+				if err := scanAppointment(rows, &appt); err != nil {
+					return err
+				}
+				appointments = append(appointments, appt)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		if appointments == nil {
+			appointments = []Appointment{}
+		}
+
+		// 4. Kirim response JSON
+		setPaginationHeaders(w, r, total, limit, offset)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appointments)
+	}
+}
+
+// CheckInAppointmentHandler menandai kedatangan pasien untuk sebuah janji temu.
+// Check-in hanya boleh dilakukan pada hari janji temu (menurut zona waktu
+// praktik dokter) dan hanya untuk janji temu yang masih terjadwal
+// (CONFIRMED atau RESCHEDULED).
+func CheckInAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appointmentID := r.PathValue("id")
+
+		// 1. Ambil jadwal, dokter & status janji temu saat ini
+		var apptDate time.Time
+		var doctorID int
+		var status string
+		err := dbpool.QueryRow(r.Context(), "SELECT appointment_date, doctor_id, status FROM appointments WHERE id = $1", appointmentID).Scan(&apptDate, &doctorID, &status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 2. Validasi status dan hari
+		if status != StatusConfirmed && status != StatusRescheduled {
+			http.Error(w, "Hanya janji temu yang masih terjadwal yang bisa check-in.", http.StatusConflict)
+			return
+		}
+		loc, err := doctorLocation(r.Context(), dbpool, doctorID, cfg)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if apptDate.In(loc).Format(dateLayout) != time.Now().In(loc).Format(dateLayout) {
+			http.Error(w, "Check-in hanya bisa dilakukan pada hari janji temu.", http.StatusConflict)
+			return
+		}
+
+		// 3. Simpan waktu check-in. Status dicek ulang di WHERE agar aman dari update bersamaan.
+		query := `UPDATE appointments SET checked_in_at = NOW(), status = $2
+                  WHERE id = $1 AND status IN ($3, $4)
+                  RETURNING ` + appointmentColumns
+
+		var appt Appointment
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, appointmentID, StatusCheckedIn, StatusConfirmed, StatusRescheduled), &appt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Hanya janji temu yang masih terjadwal yang bisa check-in.", http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal check-in janji temu: %v", err)
+			http.Error(w, "Gagal menyimpan check-in", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appt)
+	}
+}
+
+// GetNextAppointmentHandler mengembalikan janji temu terdekat yang akan datang
+// (belum dibatalkan) milik seorang pasien beserta nama dokternya, atau 404 jika
+// pasien tidak punya janji temu mendatang.
+func GetNextAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Ambil ID pasien dari URL
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Query janji temu terdekat dengan JOIN ke dokter
+		query := `
+            SELECT a.id, a.doctor_id, d.name, a.appointment_date, a.status, a.checked_in_at
+            FROM appointments a
+            JOIN doctors d ON a.doctor_id = d.id
+            WHERE a.patient_id = $1 AND a.appointment_date > NOW()
+            AND ` + activeAppointmentCondition + `
+            ORDER BY a.appointment_date, a.id
+            LIMIT 1`
+
+		var appt AppointmentResponse
+		err = withRetry(r.Context(), func() error {
+			return dbpool.QueryRow(r.Context(), query, patientID).Scan(&appt.ID, &appt.DoctorID, &appt.DoctorName, &appt.AppointmentDate, &appt.Status, &appt.CheckedInAt)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Pasien tidak memiliki janji temu mendatang", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appt)
+	}
+}
+
+// GetAppointmentCountsHandler mengembalikan jumlah janji temu di seluruh klinik
+// per status, mis. {"CONFIRMED": 12, "CANCELLED": 3}, untuk gauge dashboard.
+// Filter opsional ?from= dan ?to= (YYYY-MM-DD, inklusif) pada appointment_date.
+func GetAppointmentCountsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi rentang tanggal
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var from, to *time.Time
+		if dr.From != nil {
+			from = dr.From
+		}
+		if dr.To != nil {
+			end := dr.To.AddDate(0, 0, 1)
+			to = &end
+		}
+
+		// 2. Hitung per status dengan satu query GROUP BY
+		query := `SELECT status, COUNT(*) FROM appointments
+                  WHERE ($1::timestamptz IS NULL OR appointment_date >= $1)
+                  AND ($2::timestamptz IS NULL OR appointment_date < $2)
+                  GROUP BY status`
+
+		counts := map[string]int{}
+		err = withRetry(r.Context(), func() error {
+			clear(counts)
+			rows, err := dbpool.Query(r.Context(), query, from, to)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var status string
+				var n int
+				if err := rows.Scan(&status, &n); err != nil {
+					return err
+				}
+				counts[status] = n
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal menghitung janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(counts)
+	}
+}
+
+// maxValidateBatchSize adalah jumlah maksimum slot dalam satu request
+// POST /appointments/validate-batch.
+const maxValidateBatchSize = 20
+
+// BatchSlotRequest adalah satu usulan slot yang akan dicek.
+type BatchSlotRequest struct {
+	DoctorID  int       `json:"doctorId"`
+	PatientID int       `json:"patientId,omitempty"`
+	Date      time.Time `json:"date"`
+}
+
+// BatchSlotResult adalah hasil pengecekan satu usulan slot.
+type BatchSlotResult struct {
+	DoctorID int       `json:"doctorId"`
+	Date     Timestamp `json:"date"`
+	SlotCheckResponse
+}
+
+// ValidateAppointmentBatchHandler mengecek beberapa usulan slot sekaligus
+// (POST /appointments/validate-batch, body berupa array {doctorId, date,
+// patientId opsional}) dengan validasi yang sama seperti SlotCheckHandler,
+// tanpa menyimpan apa pun. Hasil dikembalikan sesuai urutan body.
+func ValidateAppointmentBatchHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Dekode dan batasi ukuran batch
+		var items []BatchSlotRequest
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if len(items) == 0 {
+			http.Error(w, "Minimal satu slot harus dikirim.", http.StatusUnprocessableEntity)
+			return
+		}
+		if len(items) > maxValidateBatchSize {
+			http.Error(w, fmt.Sprintf("Maksimal %d slot per request.", maxValidateBatchSize), http.StatusUnprocessableEntity)
+			return
+		}
+
+		// 2. Ambil dokter yang ada dalam satu query
+		doctorIDs := make([]int, len(items))
+		for i, item := range items {
+			doctorIDs[i] = item.DoctorID
+		}
+		rows, err := dbpool.Query(ctx, "SELECT id FROM doctors WHERE id = ANY($1)", doctorIDs)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		knownDoctors := map[int]bool{}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data dokter", http.StatusInternalServerError)
+				return
+			}
+			knownDoctors[id] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Cek setiap slot
+		results := make([]BatchSlotResult, 0, len(items))
+		for _, item := range items {
+			result := BatchSlotResult{DoctorID: item.DoctorID, Date: Timestamp{item.Date}}
+			if !knownDoctors[item.DoctorID] {
+				result.SlotCheckResponse = SlotCheckResponse{Available: false, Reason: "Dokter tidak ditemukan"}
+			} else {
+				result.SlotCheckResponse, err = checkSlotAvailability(ctx, dbpool, slotCheck{DoctorID: item.DoctorID, PatientID: item.PatientID, Date: item.Date}, cfg)
+				if err != nil {
+					writeSlotError(w, err)
+					return
+				}
+			}
+			results = append(results, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(results)
+	}
+}
+
+// LoadBucket adalah jumlah janji temu yang berlangsung bersamaan dalam satu
+// rentang waktu [start, end).
+type LoadBucket struct {
+	Start Timestamp `json:"start"`
+	End   Timestamp `json:"end"`
+	Count int       `json:"count"`
+}
+
+// GetAppointmentLoadHandler mengembalikan beban klinik pada tanggal ?date=
+// (YYYY-MM-DD, default hari ini menurut zona waktu aplikasi) per rentang
+// ?interval= (durasi Go, default 30m, antara 1m dan 24h), yaitu jumlah janji
+// temu aktif di semua dokter yang waktunya [appointmentDate, appointmentDate +
+// cfg.SlotDuration) beririsan dengan rentang tersebut. Dipakai untuk mendeteksi
+// jam-jam yang terlalu padat.
+func GetAppointmentLoadHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi tanggal dan interval
+		now := time.Now().In(cfg.Location)
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, cfg.Location)
+		if v := r.URL.Query().Get("date"); v != "" {
+			d, err := time.ParseInLocation(dateLayout, v, cfg.Location)
+			if err != nil {
+				http.Error(w, "Format date harus YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			day = d
+		}
+		interval := 30 * time.Minute
+		if v := r.URL.Query().Get("interval"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Minute || d > 24*time.Hour {
+				http.Error(w, "interval harus berupa durasi antara 1m dan 24h, mis. 30m atau 1h", http.StatusBadRequest)
+				return
+			}
+			interval = d
+		}
+		dayEnd := day.AddDate(0, 0, 1)
+
+		// 2. Ambil janji temu aktif yang beririsan dengan tanggal tersebut
+		query := `SELECT appointment_date FROM appointments
+                  WHERE appointment_date > $1 AND appointment_date < $2
+                  AND ` + activeAppointmentCondition
+
+		var starts []time.Time
+		err := withRetry(r.Context(), func() error {
+			starts = nil
+			rows, err := dbpool.Query(r.Context(), query, day.Add(-cfg.SlotDuration), dayEnd)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var t time.Time
+				if err := rows.Scan(&t); err != nil {
+					return err
+				}
+				starts = append(starts, t)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Hitung janji temu yang beririsan dengan setiap rentang
+		buckets := []LoadBucket{}
+		for start := day; start.Before(dayEnd); start = start.Add(interval) {
+			end := start.Add(interval)
+			if end.After(dayEnd) {
+				end = dayEnd
+			}
+			count := 0
+			for _, s := range starts {
+				if s.Before(end) && s.Add(cfg.SlotDuration).After(start) {
+					count++
+				}
+			}
+			buckets = append(buckets, LoadBucket{Start: Timestamp{start}, End: Timestamp{end}, Count: count})
+		}
+
+		// 4. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(buckets)
+	}
+}
+
+// UpdateAppointmentReasonRequest adalah body JSON untuk PATCH /appointments/{id}/reason.
+type UpdateAppointmentReasonRequest struct {
+	Reason *string `json:"reason"`
+}
+
+// UpdateAppointmentReasonHandler mengubah alasan kunjungan sebuah janji temu
+// tanpa menyentuh jadwalnya. Reason kosong atau null menghapus alasan.
+// Penjadwalan ulang tetap lewat PATCH /appointments/{id}.
+func UpdateAppointmentReasonHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID dan body
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+		var req UpdateAppointmentReasonRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if err := validateReason(&req.Reason); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// 2. Update hanya kolom reason
+		var appt Appointment
+		query := `UPDATE appointments SET reason = $1 WHERE id = $2 RETURNING ` + appointmentColumns
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, req.Reason, appointmentID), &appt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			log.Printf("Gagal mengubah alasan janji temu: %v", err)
+			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appt)
+	}
+}
+
+// StaffNotesRequest adalah body JSON untuk PATCH /appointments/{id}/staff-notes.
+type StaffNotesRequest struct {
+	StaffNotes *string `json:"staffNotes"`
+}
+
+// StaffNotesResponse adalah catatan internal staf pada sebuah janji temu.
+type StaffNotesResponse struct {
+	AppointmentID int     `json:"appointmentId"`
+	StaffNotes    *string `json:"staffNotes"`
+}
+
+// GetAppointmentStaffNotesHandler mengembalikan catatan internal staf sebuah
+// janji temu (GET /appointments/{id}/staff-notes, khusus admin). Kolom
+// staff_notes sengaja tidak ada di appointmentColumns agar tidak pernah ikut
+// di response janji temu yang bisa dilihat pasien.
+func GetAppointmentStaffNotesHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		resp := StaffNotesResponse{AppointmentID: appointmentID}
+		err = withRetry(r.Context(), func() error {
+			return dbpool.QueryRow(r.Context(), "SELECT staff_notes FROM appointments WHERE id = $1", appointmentID).Scan(&resp.StaffNotes)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// UpdateAppointmentStaffNotesHandler mengganti catatan internal staf sebuah
+// janji temu (PATCH /appointments/{id}/staff-notes, khusus admin). Catatan
+// kosong atau null menghapusnya.
+func UpdateAppointmentStaffNotesHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID dan body
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+		var req StaffNotesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if err := validateStaffNotes(&req.StaffNotes); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// 2. Update hanya kolom staff_notes
+		resp := StaffNotesResponse{AppointmentID: appointmentID}
+		query := `UPDATE appointments SET staff_notes = $1 WHERE id = $2 RETURNING staff_notes`
+		err = dbpool.QueryRow(r.Context(), query, req.StaffNotes, appointmentID).Scan(&resp.StaffNotes)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			log.Printf("Gagal mengubah catatan staf janji temu: %v", err)
+			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// CancelAllResponse adalah hasil pembatalan massal janji temu pasien.
+type CancelAllResponse struct {
+	Cancelled int64 `json:"cancelled"`
+}
+
+// CancelAllPatientAppointmentsHandler membatalkan semua janji temu mendatang
+// (yang belum dibatalkan) milik seorang pasien, mis. saat pasien dinonaktifkan.
+// Janji temu yang sudah lewat tidak disentuh.
+func CancelAllPatientAppointmentsHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		// 1. Kunci baris pasien agar tidak ada booking baru selama pembatalan
+		var id int
+		err = tx.QueryRow(ctx, "SELECT id FROM patients WHERE id = $1 FOR UPDATE", patientID).Scan(&id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 2. Batalkan semua janji temu mendatang
+		tag, err := tx.Exec(ctx, `UPDATE appointments SET status = $1
+                  WHERE patient_id = $2 AND appointment_date > NOW() AND `+activeAppointmentCondition,
+			StatusCancelled, patientID)
+		if err != nil {
+			log.Printf("Gagal membatalkan janji temu pasien %s: %v", maskIdentifier(patientID), err)
+			http.Error(w, "Gagal membatalkan janji temu", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit pembatalan janji temu: %v", err)
+			http.Error(w, "Gagal membatalkan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim jumlah janji temu yang dibatalkan
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CancelAllResponse{Cancelled: tag.RowsAffected()})
+	}
+}
+
+// ReactivateAppointmentHandler membatalkan pembatalan janji temu
+// (POST /appointments/{id}/reactivate). Hanya janji temu CANCELLED yang belum
+// lewat yang bisa diaktifkan lagi, dengan status CONFIRMED pada jadwal aslinya.
+// Jadwal tersebut divalidasi ulang seperti booking baru; jika slotnya sudah
+// diambil pasien lain atau tidak lagi valid, dikembalikan 409 dan pasien
+// perlu memilih jadwal baru.
+func ReactivateAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Ambil janji temu yang dibatalkan
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+		var doctorID, patientID int
+		var date time.Time
+		var status string
+		err = dbpool.QueryRow(ctx, "SELECT doctor_id, patient_id, appointment_date, status FROM appointments WHERE id = $1", appointmentID).Scan(&doctorID, &patientID, &date, &status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		if status != StatusCancelled {
+			http.Error(w, fmt.Sprintf("Hanya janji temu %s yang dapat diaktifkan kembali (status saat ini %s).", StatusCancelled, status), http.StatusConflict)
+			return
+		}
+		if !date.After(time.Now()) {
+			http.Error(w, "Jadwal janji temu sudah lewat, silakan buat janji temu baru.", http.StatusConflict)
+			return
+		}
+
+		// 2. Validasi ulang jadwal aslinya seperti booking baru
+		if err := checkPatientActive(ctx, dbpool, patientID); err != nil {
+			writeSlotError(w, err)
+			return
+		}
+		err = validateAppointmentSlot(ctx, dbpool, slotCheck{
+			DoctorID:  doctorID,
+			PatientID: patientID,
+			Date:      date,
+			ExcludeID: appointmentID,
+		}, cfg)
+		if err != nil {
+			var slotErr *SlotError
+			if errors.As(err, &slotErr) {
+				http.Error(w, slotErr.Message+" Silakan jadwalkan ulang ke waktu lain.", http.StatusConflict)
+				return
+			}
+			writeSlotError(w, err)
+			return
+		}
+		if !isAdmin(r, cfg) {
+			if err := checkPatientDailyLimit(ctx, dbpool, patientID, doctorID, date, cfg); err != nil {
+				writeSlotError(w, err)
+				return
+			}
+		}
+
+		if err := expireStaleHolds(ctx, dbpool); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
+
+		// 3. Aktifkan kembali; slot yang sudah terisi ditolak oleh unique index
+		query := `UPDATE appointments SET status = $2
+                  WHERE id = $1 AND status = $3
+                  RETURNING ` + appointmentColumns
+
+		var appt Appointment
+		err = scanAppointment(dbpool.QueryRow(ctx, query, appointmentID, StatusConfirmed, StatusCancelled), &appt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Status janji temu sudah berubah dan tidak dapat diaktifkan kembali.", http.StatusConflict)
+				return
+			}
+			if _, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, "Slot janji temu ini sudah diambil pasien lain. Silakan jadwalkan ulang ke waktu lain.", http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal mengaktifkan kembali janji temu: %v", err)
+			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appt)
+	}
+}
+
+// DaySheetEntry adalah satu baris daftar pasien hari ini: janji temu beserta
+// data pasien dan dokternya.
+type DaySheetEntry struct {
+	AppointmentID   int        `json:"appointmentId"`
+	AppointmentDate Timestamp  `json:"appointmentDate"`
+	Status          string     `json:"status"`
+	CheckedInAt     *Timestamp `json:"checkedInAt"`
+	PatientID       int        `json:"patientId"`
+	PatientName     string     `json:"patientName"`
+	DoctorID        int        `json:"doctorId"`
+	DoctorName      string     `json:"doctorName"`
+}
+
+// GetPatientsTodayHandler mengembalikan pasien yang punya janji temu hari ini
+// (menurut zona waktu aplikasi) di seluruh klinik beserta jam dan dokternya,
+// diurutkan menurut jam (GET /patients/today). Dipakai untuk mencetak daftar
+// harian. Janji temu yang dibatalkan dan slot yang masih di-hold tidak ikut.
+func GetPatientsTodayHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().In(cfg.Location)
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, cfg.Location)
+
+		query := `SELECT a.id, a.appointment_date, a.status, a.checked_in_at, p.id, p.full_name, d.id, d.name
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.appointment_date >= $1 AND a.appointment_date < $2
+                  AND a.status NOT IN ($3, $4)
+                  ORDER BY a.appointment_date, d.name, a.id`
+
+		var entries []DaySheetEntry
+		err := withRetry(r.Context(), func() error {
+			entries = []DaySheetEntry{}
+			rows, err := dbpool.Query(r.Context(), query, day, day.AddDate(0, 0, 1), StatusCancelled, StatusHeld)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var e DaySheetEntry
+				if err := rows.Scan(&e.AppointmentID, &e.AppointmentDate, &e.Status, &e.CheckedInAt, &e.PatientID, &e.PatientName, &e.DoctorID, &e.DoctorName); err != nil {
+					return err
+				}
+				entries = append(entries, e)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateDefaultAppointmentStatus(t *testing.T) {
+	for _, status := range []string{StatusConfirmed, StatusRescheduled, StatusCheckedIn} {
+		if err := ValidateDefaultAppointmentStatus(status); err != nil {
+			t.Errorf("ValidateDefaultAppointmentStatus(%q): %v", status, err)
+		}
+	}
+	for _, status := range []string{"", "CONFRIMED", "confirmed", StatusHeld} {
+		if err := ValidateDefaultAppointmentStatus(status); err == nil {
+			t.Errorf("ValidateDefaultAppointmentStatus(%q) = nil, want error", status)
+		}
+	}
+}
+
+func TestGetAllAppointmentsFiltersByCreatedDate(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	actor := fmt.Sprintf("test-%d", doctorID)
+
+	// Keduanya dijadwalkan besok, tetapi yang pertama dibuat jauh hari sebelumnya
+	old := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	recent := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 10), StatusConfirmed)
+	execSQL(t, pool, "UPDATE appointments SET created_at = '2020-01-15 10:00:00+00' WHERE id = $1", old)
+	execSQL(t, pool, "UPDATE appointments SET created_by = $1 WHERE id IN ($2, $3)", actor, old, recent)
+
+	handler := GetAllAppointmentsHandler(pool, cfg)
+	tomorrow := tomorrowAt(cfg, 0).Format(dateLayout)
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "tanggal dibuat", query: "createdFrom=2020-01-15&createdTo=2020-01-15", want: []int{old}},
+		{name: "tanggal janji temu", query: "from=" + tomorrow + "&to=" + tomorrow, want: []int{recent, old}},
+		{name: "tanggal dibuat tanpa hasil", query: "createdFrom=2020-01-16&createdTo=2020-01-16", want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, httptest.NewRequest(http.MethodGet, "/appointments?createdBy="+actor+"&"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			if got := appointmentIDs(decodeJSON[[]Appointment](t, rec)); !slices.Equal(got, tt.want) {
+				t.Fatalf("janji temu = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllAppointmentsRejectsInvalidCreatedDate(t *testing.T) {
+	// Filter divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	rec := serve(GetAllAppointmentsHandler(nil, testConfig()), httptest.NewRequest(http.MethodGet, "/appointments?createdFrom=15-01-2020", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+// checkInRoute adalah pola route CheckInAppointmentHandler di cmd/api/main.go.
+const checkInRoute = "PATCH /appointments/{id}/check-in"
+
+func TestCheckInAppointment(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	handler := routed(checkInRoute, CheckInAppointmentHandler(pool, cfg))
+
+	tests := []struct {
+		name   string
+		date   time.Time
+		status string
+		want   int
+	}{
+		{name: "hari ini", date: time.Now().Truncate(time.Minute), status: StatusConfirmed, want: http.StatusOK},
+		{name: "besok", date: tomorrowAt(cfg, 9), status: StatusConfirmed, want: http.StatusConflict},
+		{name: "sudah dibatalkan", date: time.Now().Truncate(time.Minute).Add(time.Minute), status: StatusCancelled, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := insertTestAppointment(t, pool, patientID, doctorID, tt.date, tt.status)
+			rec := serve(handler, httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/appointments/%d/check-in", id), nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			appt := decodeJSON[Appointment](t, rec)
+			if appt.Status != StatusCheckedIn || appt.CheckedInAt == nil {
+				t.Fatalf("janji temu = %+v, want CHECKED_IN dengan checkedInAt", appt)
+			}
+		})
+	}
+}
+
+func TestCheckInUsesDoctorTimezone(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Satu jam lagi masih hari ini di zona pukul 12, tetapi sudah besok di zona pukul 23.
+	date := time.Now().Add(time.Hour).Truncate(time.Minute)
+	handler := routed(checkInRoute, CheckInAppointmentHandler(pool, cfg))
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want int
+	}{
+		{name: "masih hari yang sama", loc: zoneWithLocalHour(t, 12), want: http.StatusOK},
+		{name: "sudah besok", loc: zoneWithLocalHour(t, 23), want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doctorID := createTestDoctor(t, pool)
+			execSQL(t, pool, "UPDATE doctors SET timezone = $1 WHERE id = $2", tt.loc.String(), doctorID)
+			id := insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, date, StatusConfirmed)
+			rec := serve(handler, httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/appointments/%d/check-in", id), nil))
+			if rec.Code != tt.want {
+				t.Fatalf("zona %s: status = %d, want %d (%s)", tt.loc, rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCheckInUnknownAppointmentReturns404(t *testing.T) {
+	pool := testPool(t, nil)
+	rec := serve(routed(checkInRoute, CheckInAppointmentHandler(pool, testConfig())), httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/appointments/%d/check-in", math.MaxInt32), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+const nextAppointmentRoute = "GET /patients/{id}/appointments/next"
+
+func TestGetNextAppointment(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	handler := routed(nextAppointmentRoute, GetNextAppointmentHandler(pool))
+	yesterday := tomorrowAt(cfg, 9).AddDate(0, 0, -2)
+
+	withFuture := createTestPatient(t, pool)
+	insertTestAppointment(t, pool, withFuture, doctorID, yesterday, StatusCheckedIn)
+	insertTestAppointment(t, pool, withFuture, doctorID, tomorrowAt(cfg, 9), StatusCancelled)
+	next := insertTestAppointment(t, pool, withFuture, doctorID, tomorrowAt(cfg, 10), StatusConfirmed)
+	insertTestAppointment(t, pool, withFuture, doctorID, tomorrowAt(cfg, 11), StatusConfirmed)
+
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/patients/%d/appointments/next", withFuture), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pasien dengan janji temu mendatang: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if got := decodeJSON[AppointmentResponse](t, rec); got.ID != next || got.DoctorName != "Dokter Test" {
+		t.Errorf("janji temu berikutnya = %+v, want id %d dengan nama dokter", got, next)
+	}
+
+	onlyPast := createTestPatient(t, pool)
+	insertTestAppointment(t, pool, onlyPast, doctorID, yesterday.Add(time.Hour), StatusConfirmed)
+	rec = serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/patients/%d/appointments/next", onlyPast), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("pasien yang hanya punya janji temu lampau: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestGetAppointmentCountsByStatus(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	day := tomorrowAt(cfg, 9).AddDate(4, 0, 0)
+	target := "/appointments/counts?from=" + day.Format(dateLayout) + "&to=" + day.Format(dateLayout)
+	handler := GetAppointmentCountsHandler(pool, cfg)
+
+	counts := func() map[string]int {
+		t.Helper()
+		rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+		}
+		return decodeJSON[map[string]int](t, rec)
+	}
+
+	// Tabel dipakai bersama, jadi yang dibandingkan adalah selisihnya.
+	before := counts()
+	for i, status := range []string{StatusConfirmed, StatusConfirmed, StatusConfirmed, StatusCancelled, StatusCancelled, StatusCheckedIn} {
+		insertTestAppointment(t, pool, patientID, doctorID, day.Add(time.Duration(i)*cfg.SlotDuration), status)
+	}
+	// Di luar rentang, tidak ikut dihitung.
+	insertTestAppointment(t, pool, patientID, doctorID, day.AddDate(0, 0, 1), StatusConfirmed)
+	after := counts()
+
+	want := map[string]int{StatusConfirmed: 3, StatusCancelled: 2, StatusCheckedIn: 1, StatusRescheduled: 0}
+	for status, n := range want {
+		if got := after[status] - before[status]; got != n {
+			t.Errorf("%s bertambah %d, want %d", status, got, n)
+		}
+	}
+}
+
+func TestGetAppointmentCountsRejectsInvalidRange(t *testing.T) {
+	// Rentang divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	for _, query := range []string{"?from=2025-13-01", "?from=2025-02-10&to=2025-02-01"} {
+		rec := serve(GetAppointmentCountsHandler(nil, testConfig()), httptest.NewRequest(http.MethodGet, "/appointments/counts"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+const reasonRoute = "PATCH /appointments/{id}/reason"
+
+func TestUpdateAppointmentReasonKeepsDate(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	date := tomorrowAt(cfg, 9)
+	id := insertTestAppointment(t, pool, createTestPatient(t, pool), createTestDoctor(t, pool), date, StatusConfirmed)
+	handler := routed(reasonRoute, UpdateAppointmentReasonHandler(pool))
+	target := fmt.Sprintf("/appointments/%d/reason", id)
+
+	rec := serveJSON(t, handler, http.MethodPatch, target, map[string]any{"reason": "  Kontrol tekanan darah "})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	appt := decodeJSON[Appointment](t, rec)
+	if appt.Reason == nil || *appt.Reason != "Kontrol tekanan darah" {
+		t.Errorf("reason = %v, want %q", appt.Reason, "Kontrol tekanan darah")
+	}
+	if !appt.AppointmentDate.Equal(date) || appt.Status != StatusConfirmed {
+		t.Errorf("janji temu = %s %s, want %s %s tidak berubah", appt.AppointmentDate, appt.Status, date, StatusConfirmed)
+	}
+
+	rec = serveJSON(t, handler, http.MethodPatch, target, map[string]any{"reason": strings.Repeat("a", maxReasonLength+1)})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("alasan terlalu panjang: status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, handler, http.MethodPatch, "/appointments/0/reason", map[string]any{"reason": "Kontrol"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("janji temu yang tidak ada: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestCancelAllPatientAppointmentsLeavesPastUntouched(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	yesterday := tomorrowAt(cfg, 9).AddDate(0, 0, -2)
+
+	pastConfirmed := insertTestAppointment(t, pool, patientID, doctorID, yesterday, StatusConfirmed)
+	pastCheckedIn := insertTestAppointment(t, pool, patientID, doctorID, yesterday.Add(time.Hour), StatusCheckedIn)
+	upcoming := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	rescheduled := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 10), StatusRescheduled)
+	alreadyCancelled := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusCancelled)
+	other := insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, 12), StatusConfirmed)
+
+	handler := routed("POST /patients/{id}/appointments/cancel-all", CancelAllPatientAppointmentsHandler(pool))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/patients/%d/appointments/cancel-all", patientID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if got := decodeJSON[CancelAllResponse](t, rec); got.Cancelled != 2 {
+		t.Errorf("cancelled = %d, want 2", got.Cancelled)
+	}
+
+	for _, tc := range []struct {
+		name string
+		id   int
+		want string
+	}{
+		{"lampau CONFIRMED", pastConfirmed, StatusConfirmed},
+		{"lampau CHECKED_IN", pastCheckedIn, StatusCheckedIn},
+		{"mendatang CONFIRMED", upcoming, StatusCancelled},
+		{"mendatang RESCHEDULED", rescheduled, StatusCancelled},
+		{"sudah dibatalkan", alreadyCancelled, StatusCancelled},
+		{"milik pasien lain", other, StatusConfirmed},
+	} {
+		if got := appointmentStatus(pool, tc.id); got != tc.want {
+			t.Errorf("%s: status = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGetAllAppointmentsFiltersByActor(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	create := CreateAppointmentHandler(pool, cfg)
+	book := func(actor string, hour int) Appointment {
+		t.Helper()
+		req := newJSONRequest(t, http.MethodPost, "/appointments", map[string]any{
+			"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": tomorrowAt(cfg, hour),
+		})
+		req.Header.Set("X-Actor-ID", actor)
+		rec := serve(create, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("booking oleh %s: status = %d, want 201 (%s)", actor, rec.Code, rec.Body)
+		}
+		return decodeJSON[Appointment](t, rec)
+	}
+	alice := fmt.Sprintf("alice-%d", doctorID)
+	budi := fmt.Sprintf("budi-%d", doctorID)
+	first := book(alice, 9)
+	second := book(alice, 10)
+	other := book(budi, 11)
+	if first.CreatedBy == nil || *first.CreatedBy != alice {
+		t.Fatalf("createdBy = %v, want %s", first.CreatedBy, alice)
+	}
+
+	handler := GetAllAppointmentsHandler(pool, cfg)
+	tests := []struct {
+		actor string
+		want  []int
+	}{
+		{actor: alice, want: []int{second.ID, first.ID}},
+		{actor: budi, want: []int{other.ID}},
+		{actor: fmt.Sprintf("tidak-ada-%d", doctorID), want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.actor, func(t *testing.T) {
+			rec := serve(handler, httptest.NewRequest(http.MethodGet, "/appointments?createdBy="+tt.actor, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			if got := appointmentIDs(decodeJSON[[]Appointment](t, rec)); !slices.Equal(got, tt.want) {
+				t.Fatalf("janji temu = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAppointmentLoadCountsOverlaps(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Tanggal jauh di depan agar janji temu test lain tidak ikut terhitung
+	day := tomorrowAt(cfg, 0).AddDate(5, 0, 0)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	first, second, third := createTestDoctor(t, pool), createTestDoctor(t, pool), createTestDoctor(t, pool)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), first, at(9, 0), StatusConfirmed)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), second, at(9, 0), StatusConfirmed)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), first, at(9, 30), StatusConfirmed)
+	// 09:15-09:45 beririsan dengan rentang 09:00 dan 09:30
+	insertTestAppointment(t, pool, createTestPatient(t, pool), third, at(9, 15), StatusConfirmed)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), third, at(10, 0), StatusCancelled)
+
+	handler := GetAppointmentLoadHandler(pool, cfg)
+	tests := []struct {
+		interval string
+		want     map[time.Time]int
+	}{
+		{interval: "30m", want: map[time.Time]int{at(8, 30): 0, at(9, 0): 3, at(9, 30): 2, at(10, 0): 0}},
+		{interval: "1h", want: map[time.Time]int{at(8, 0): 0, at(9, 0): 4, at(10, 0): 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			target := fmt.Sprintf("/appointments/load?date=%s&interval=%s", day.Format(dateLayout), tt.interval)
+			rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			counts := map[time.Time]int{}
+			for _, b := range decodeJSON[[]LoadBucket](t, rec) {
+				counts[b.Start.UTC()] = b.Count
+			}
+			for start, want := range tt.want {
+				if got, ok := counts[start.UTC()]; !ok || got != want {
+					t.Errorf("rentang %s: count = %d (ada: %v), want %d", start.Format("15:04"), got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAppointmentBatchMixedSlots(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+
+	items := []BatchSlotRequest{
+		{DoctorID: doctorID, Date: tomorrowAt(cfg, 9)},
+		{DoctorID: doctorID, Date: tomorrowAt(cfg, 9).Add(30 * time.Minute)},
+		{DoctorID: doctorID, Date: tomorrowAt(cfg, 20)},
+		{DoctorID: doctorID, Date: tomorrowAt(cfg, 11)},
+	}
+	rec := serveJSON(t, ValidateAppointmentBatchHandler(pool, cfg), http.MethodPost, "/appointments/validate-batch", items)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	results := decodeJSON[[]BatchSlotResult](t, rec)
+	want := []bool{false, true, false, true}
+	if len(results) != len(want) {
+		t.Fatalf("jumlah hasil = %d, want %d", len(results), len(want))
+	}
+	for i, res := range results {
+		if !res.Date.Equal(items[i].Date) || res.Available != want[i] {
+			t.Errorf("hasil %d = %+v, want %s dengan available %v", i, res, items[i].Date, want[i])
+		}
+		if !res.Available && res.Reason == "" {
+			t.Errorf("hasil %d tidak tersedia tanpa alasan", i)
+		}
+	}
+
+	// Validasi tidak menyimpan janji temu apa pun
+	var count int
+	pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM appointments WHERE doctor_id = $1", doctorID).Scan(&count)
+	if count != 1 {
+		t.Errorf("jumlah janji temu dokter = %d, want 1", count)
+	}
+}
+
+const reactivateRoute = "POST /appointments/{id}/reactivate"
+
+func TestReactivateCancelledAppointment(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	handler := routed(reactivateRoute, ReactivateAppointmentHandler(pool, cfg))
+
+	tests := []struct {
+		name  string
+		hour  int
+		taken bool
+		want  int
+	}{
+		{name: "slot asli masih kosong", hour: 9, want: http.StatusOK},
+		{name: "slot asli sudah diambil", hour: 10, taken: true, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, tt.hour), StatusCancelled)
+			if tt.taken {
+				insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, tt.hour), StatusConfirmed)
+			}
+
+			rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/appointments/%d/reactivate", id), nil)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusConflict {
+				if !strings.Contains(rec.Body.String(), "jadwalkan ulang") {
+					t.Errorf("body = %q, want saran untuk menjadwalkan ulang", rec.Body)
+				}
+				if got := appointmentStatus(pool, id); got != StatusCancelled {
+					t.Errorf("status janji temu = %s, want tetap %s", got, StatusCancelled)
+				}
+				return
+			}
+			appt := decodeJSON[Appointment](t, rec)
+			if appt.Status != StatusConfirmed || !appt.AppointmentDate.Equal(tomorrowAt(cfg, tt.hour)) {
+				t.Errorf("janji temu = %+v, want CONFIRMED pada jadwal aslinya", appt)
+			}
+		})
+	}
+}
+
+func TestStaffNotesVisibleOnlyToAdmin(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.AdminAPIKey = "rahasia"
+	patientID := createTestPatient(t, pool)
+	id := insertTestAppointment(t, pool, patientID, createTestDoctor(t, pool), tomorrowAt(cfg, 9), StatusConfirmed)
+	const note = "Pasien sensitif terhadap jarum, siapkan pendamping."
+	asAdmin := func(req *http.Request) *http.Request {
+		req.Header.Set("X-Admin-Key", cfg.AdminAPIKey)
+		return req
+	}
+	target := fmt.Sprintf("/appointments/%d/staff-notes", id)
+
+	update := routed("PATCH /appointments/{id}/staff-notes", RequireAdmin(cfg, UpdateAppointmentStaffNotesHandler(pool)))
+	rec := serve(update, asAdmin(newJSONRequest(t, http.MethodPatch, target, map[string]any{"staffNotes": note})))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ubah catatan staf: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+
+	get := routed("GET /appointments/{id}/staff-notes", RequireAdmin(cfg, GetAppointmentStaffNotesHandler(pool)))
+	rec = serve(get, asAdmin(newJSONRequest(t, http.MethodGet, target, nil)))
+	if resp := decodeJSON[StaffNotesResponse](t, rec); rec.Code != http.StatusOK || resp.StaffNotes == nil || *resp.StaffNotes != note {
+		t.Fatalf("admin: status = %d, staffNotes = %v, want 200 %q", rec.Code, resp.StaffNotes, note)
+	}
+	if rec := serve(get, newJSONRequest(t, http.MethodGet, target, nil)); rec.Code != http.StatusForbidden {
+		t.Fatalf("bukan admin: status = %d, want 403", rec.Code)
+	}
+
+	// Bacaan milik pasien tidak pernah memuat catatan staf
+	for _, tt := range []struct {
+		route   string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"GET /patients/{id}/appointments", GetAppointmentsByPatientIDHandler(pool, cfg), fmt.Sprintf("/patients/%d/appointments", patientID)},
+		{"GET /patients/{id}/profile", GetPatientProfileHandler(pool), fmt.Sprintf("/patients/%d/profile", patientID)},
+	} {
+		rec := serve(routed(tt.route, tt.handler), asAdmin(newJSONRequest(t, http.MethodGet, tt.target, nil)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", tt.target, rec.Code, rec.Body)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "staffNotes") || strings.Contains(body, note) {
+			t.Errorf("%s memuat catatan staf: %s", tt.target, body)
+		}
+	}
+}
+
+func TestGetPatientsTodayWithSeveralPatients(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Sekarang pukul 10 di zona waktu aplikasi, jadi 08:00-15:00 masih hari ini
+	cfg.Location = zoneWithLocalHour(t, 10)
+	now := time.Now().In(cfg.Location)
+	today := func(hour int) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, cfg.Location)
+	}
+	doctorID := createTestDoctor(t, pool)
+	patient := func(name string) int {
+		id := createTestPatient(t, pool)
+		execSQL(t, pool, "UPDATE patients SET full_name = $1 WHERE id = $2", name, id)
+		return id
+	}
+	// Dimasukkan tidak berurutan; yang sudah lewat pagi ini tetap ikut
+	late := insertTestAppointment(t, pool, patient("Citra"), doctorID, today(15), StatusConfirmed)
+	early := insertTestAppointment(t, pool, patient("Ani"), doctorID, today(8), StatusCheckedIn)
+	middle := insertTestAppointment(t, pool, patient("Budi"), doctorID, today(11), StatusRescheduled)
+	cancelled := insertTestAppointment(t, pool, patient("Dewi"), doctorID, today(12), StatusCancelled)
+	tomorrow := insertTestAppointment(t, pool, patient("Eko"), doctorID, today(9).AddDate(0, 0, 1), StatusConfirmed)
+
+	rec := serve(GetPatientsTodayHandler(pool, cfg), httptest.NewRequest(http.MethodGet, "/patients/today", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	// Daftar mencakup seluruh klinik; ambil hanya janji temu dokter test ini
+	var got []DaySheetEntry
+	for _, e := range decodeJSON[[]DaySheetEntry](t, rec) {
+		if e.DoctorID == doctorID {
+			got = append(got, e)
+		}
+	}
+	want := []struct {
+		id   int
+		name string
+	}{{early, "Ani"}, {middle, "Budi"}, {late, "Citra"}}
+	if len(got) != len(want) {
+		t.Fatalf("daftar hari ini = %+v, want %d janji temu (tanpa %d yang dibatalkan dan %d besok)", got, len(want), cancelled, tomorrow)
+	}
+	for i, w := range want {
+		if got[i].AppointmentID != w.id || got[i].PatientName != w.name || got[i].DoctorName != "Dokter Test" {
+			t.Errorf("baris %d = %+v, want janji temu %d milik %s", i, got[i], w.id, w.name)
+		}
+	}
+}
+
+func TestCreateAndFilterByPriority(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	actor := fmt.Sprintf("triase-%d", doctorID)
+	create := CreateAppointmentHandler(pool, cfg)
+	book := func(priority string, hour int) Appointment {
+		t.Helper()
+		body := map[string]any{"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": tomorrowAt(cfg, hour)}
+		if priority != "" {
+			body["priority"] = priority
+		}
+		req := newJSONRequest(t, http.MethodPost, "/appointments", body)
+		req.Header.Set("X-Actor-ID", actor)
+		rec := serve(create, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("booking prioritas %q: status = %d, want 201 (%s)", priority, rec.Code, rec.Body)
+		}
+		return decodeJSON[Appointment](t, rec)
+	}
+
+	routine := book("", 9)
+	urgent := book("urgent", 10)
+	emergency := book(PriorityEmergency, 11)
+	if routine.Priority != PriorityRoutine || urgent.Priority != PriorityUrgent || emergency.Priority != PriorityEmergency {
+		t.Fatalf("prioritas = %s, %s, %s, want ROUTINE, URGENT, EMERGENCY", routine.Priority, urgent.Priority, emergency.Priority)
+	}
+
+	rec := serveJSON(t, create, http.MethodPost, "/appointments", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": tomorrowAt(cfg, 12), "priority": "HIGH",
+	})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("prioritas tidak dikenal: status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+
+	handler := GetAllAppointmentsHandler(pool, cfg)
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "filter URGENT", query: "priority=URGENT", want: []int{urgent.ID}},
+		{name: "filter huruf kecil", query: "priority=routine", want: []int{routine.ID}},
+		{name: "urut prioritas", query: "sort=priority", want: []int{emergency.ID, urgent.ID, routine.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, httptest.NewRequest(http.MethodGet, "/appointments?createdBy="+actor+"&"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			if got := appointmentIDs(decodeJSON[[]Appointment](t, rec)); !slices.Equal(got, tt.want) {
+				t.Fatalf("janji temu = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
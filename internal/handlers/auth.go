@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+)
+
+// isAdmin mengecek apakah request dikirim oleh admin, yaitu jika header
+// X-Admin-Key sama dengan ADMIN_API_KEY. Selama kunci admin belum
+// dikonfigurasi, tidak ada request yang dianggap admin.
+func isAdmin(r *http.Request, cfg *config.Config) bool {
+	if cfg.AdminAPIKey == "" {
+		return false
+	}
+	key := r.Header.Get("X-Admin-Key")
+	return subtle.ConstantTimeCompare([]byte(key), []byte(cfg.AdminAPIKey)) == 1
+}
+
+// maxActorIDLength sama dengan panjang kolom appointments.created_by.
+const maxActorIDLength = 64
+
+// actorID mengembalikan ID petugas/pengguna yang mengirim request dari header
+// X-Actor-ID, untuk dicatat sebagai pembuat data. nil jika header kosong atau
+// lebih panjang dari maxActorIDLength. Nilai ini dikirim oleh gateway/aplikasi
+// klinik dan tidak diverifikasi di sini.
+func actorID(r *http.Request) *string {
+	id := strings.TrimSpace(r.Header.Get("X-Actor-ID"))
+	if id == "" || len(id) > maxActorIDLength {
+		return nil
+	}
+	return &id
+}
+
+// RequireAdmin menolak request yang bukan dari admin dengan 403.
+// Dipasang pada endpoint pengelolaan data klinik.
+func RequireAdmin(cfg *config.Config, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r, cfg) {
+			http.Error(w, "Endpoint ini hanya untuk admin", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// querier adalah method yang dimiliki *pgxpool.Pool maupun pgx.Tx, sehingga
+// helper di bawah bisa dipakai di dalam maupun di luar transaksi.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// isoWeekday mengubah hari dari time.Weekday (Minggu = 0) ke format
+// yang dipakai tabel doctor_schedules (Senin = 1, Minggu = 7).
+func isoWeekday(t time.Time) int {
+	day := int(t.Weekday())
+	if day == 0 {
+		return 7
+	}
+	return day
+}
+
+// getWorkingHours mengambil jam kerja dokter pada hari tertentu sebagai
+// offset dari tengah malam. found bernilai false jika dokter tidak praktik di hari itu.
+// Untuk shift malam (end_time sebelum start_time), end lebih dari 24 jam.
+func getWorkingHours(ctx context.Context, dbpool querier, doctorID, dayOfWeek int) (start, end time.Duration, found bool, err error) {
+	var startTime, endTime pgtype.Time
+	err = dbpool.QueryRow(ctx, "SELECT start_time, end_time FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = $2", doctorID, dayOfWeek).Scan(&startTime, &endTime)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	start = time.Duration(startTime.Microseconds) * time.Microsecond
+	end = time.Duration(endTime.Microseconds) * time.Microsecond
+	if end < start {
+		end += 24 * time.Hour
+	}
+	return start, end, true, nil
+}
+
+// doctorBuffer mengambil jeda minimum antar janji temu seorang dokter
+// (doctors.buffer_minutes). Dokter yang tidak ditemukan dianggap tanpa jeda.
+func doctorBuffer(ctx context.Context, dbpool querier, doctorID int) (time.Duration, error) {
+	var minutes int
+	err := dbpool.QueryRow(ctx, "SELECT buffer_minutes FROM doctors WHERE id = $1", doctorID).Scan(&minutes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return time.Duration(minutes) * time.Minute, err
+}
+
+// computeOpenSlots menghitung slot yang masih kosong untuk seorang dokter pada
+// tanggal kalender date (tahun/bulan/hari dari date apa adanya). Jam kerja
+// dibaca menurut zona waktu praktik dokter. Slot dibentuk dari jam kerja
+// mingguan dengan panjang cfg.SlotDuration, lalu dikurangi hari libur dan janji
+// temu yang masih aktif (termasuk slot yang sedang di-hold).
+func computeOpenSlots(ctx context.Context, dbpool querier, doctorID int, date time.Time, cfg *config.Config) ([]time.Time, error) {
+	_, open, err := computeDaySlots(ctx, dbpool, doctorID, date, cfg)
+	return open, err
+}
+
+// computeDaySlots sama dengan computeOpenSlots, tetapi juga mengembalikan semua
+// slot pada jam kerja hari itu (all), termasuk yang sudah terisi. Pada hari
+// libur klinik atau dokter, keduanya kosong. Jika dokter mengatur
+// buffer_minutes, slot yang terlalu dekat dengan janji temu lain juga tidak
+// termasuk open.
+func computeDaySlots(ctx context.Context, dbpool querier, doctorID int, date time.Time, cfg *config.Config) (all, open []time.Time, err error) {
+	slotDuration := cfg.SlotDuration
+	loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	// 1. Klinik atau dokter libur pada tanggal ini?
+	holiday, err := isHoliday(ctx, dbpool, day, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if holiday {
+		return []time.Time{}, []time.Time{}, nil
+	}
+	var isOff bool
+	err = dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctor_time_off WHERE doctor_id = $1 AND off_date = $2)", doctorID, day.Format(dateLayout)).Scan(&isOff)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isOff {
+		return []time.Time{}, []time.Time{}, nil
+	}
+
+	// 2. Ambil jam kerja pada hari tersebut
+	start, end, found, err := getWorkingHours(ctx, dbpool, doctorID, isoWeekday(day))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return []time.Time{}, []time.Time{}, nil
+	}
+
+	// 3. Ambil janji temu yang sudah terisi selama jam kerja ini
+	// (untuk shift malam, sampai keesokan harinya), diperlebar sebesar jeda dokter
+	buffer, err := doctorBuffer(ctx, dbpool, doctorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	margin := time.Duration(0)
+	if buffer > 0 {
+		margin = slotDuration + buffer
+	}
+	rows, err := dbpool.Query(ctx, `SELECT appointment_date FROM appointments
+                  WHERE doctor_id = $1 AND appointment_date > $2 AND appointment_date < $3
+                  AND `+activeAppointmentCondition, doctorID, day.Add(start).Add(-margin-time.Nanosecond), day.Add(end).Add(margin))
+	if err != nil {
+		return nil, nil, err
+	}
+	var booked []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		booked = append(booked, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Susun slot dan buang yang sudah terisi
+	all, open = []time.Time{}, []time.Time{}
+	if slotDuration <= 0 {
+		return all, open, nil
+	}
+	for offset := start; offset+slotDuration <= end; offset += slotDuration {
+		slotStart := day.Add(offset)
+		slotEnd := slotStart.Add(slotDuration)
+		all = append(all, slotStart)
+		taken := false
+		for _, b := range booked {
+			if !b.Before(slotStart) && b.Before(slotEnd) {
+				taken = true
+				break
+			}
+			// Terlalu dekat dengan janji temu lain menurut jeda dokter
+			if buffer > 0 && b.After(slotStart.Add(-margin)) && b.Before(slotEnd.Add(buffer)) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			open = append(open, slotStart)
+		}
+	}
+	return all, open, nil
+}
+
+// findNextOpenSlot mencari slot kosong pertama milik dokter yang dimulai
+// setelah after, hari demi hari memakai computeOpenSlots, paling jauh
+// horizonDays hari ke depan. Setiap kandidat juga divalidasi dengan
+// validateAppointmentSlot (c.Date diisi otomatis), sehingga slot yang bentrok
+// dengan janji temu lain milik pasien dilewati. found bernilai false jika
+// tidak ada slot dalam rentang tersebut.
+func findNextOpenSlot(ctx context.Context, dbpool querier, c slotCheck, after time.Time, horizonDays int, cfg *config.Config) (slot time.Time, found bool, err error) {
+	loc, err := doctorLocation(ctx, dbpool, c.DoctorID, cfg)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	start := after.In(loc)
+	for i := 0; i <= horizonDays; i++ {
+		slots, err := computeOpenSlots(ctx, dbpool, c.DoctorID, start.AddDate(0, 0, i), cfg)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		for _, s := range slots {
+			if !s.After(after) {
+				continue
+			}
+			c.Date = s
+			err := validateAppointmentSlot(ctx, dbpool, c, cfg)
+			var slotErr *SlotError
+			if errors.As(err, &slotErr) {
+				continue
+			}
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			return s, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// SlotError adalah alasan sebuah jadwal janji temu ditolak.
+// Message siap dikirim ke client dengan kode Status.
+type SlotError struct {
+	Status  int
+	Message string
+}
+
+func (e *SlotError) Error() string { return e.Message }
+
+// slotCheck berisi data jadwal yang akan divalidasi oleh validateAppointmentSlot.
+type slotCheck struct {
+	DoctorID  int
+	PatientID int
+	Date      time.Time
+	// ExcludeID adalah ID janji temu yang sedang dipindahkan (0 untuk janji temu baru).
+	ExcludeID int
+}
+
+// validateAppointmentSlot menjalankan semua pengecekan jadwal yang dipakai
+// bersama oleh pembuatan dan penjadwalan ulang janji temu:
+//  1. jam janji temu tidak punya detik (mis. 09:07:23 dan 09:00:00.5 ditolak),
+//  2. tanggal tersebut bukan hari libur nasional dan dokter tidak sedang libur,
+//  3. jadwal berada di dalam jam kerja dokter dan tepat di awal salah satu
+//     slot (kelipatan slotDuration dari jam mulai praktik),
+//  4. pasien tidak punya janji temu lain yang bertumpuk dalam slotDuration,
+//  5. jika dokter mengatur buffer_minutes, ada jeda minimal sebesar itu antara
+//     janji temu ini dan janji temu dokter lainnya (sebelum maupun sesudahnya).
+//
+// Hari, tanggal, dan jam janji temu dibaca menurut zona waktu praktik dokter
+// (default-nya zona waktu aplikasi), apa pun offset yang dikirim client.
+//
+// Bentrok slot dokter tidak dicek di sini; itu dijaga oleh unique index
+// appointments_doctor_slot_unique saat INSERT/UPDATE.
+// Error bertipe *SlotError berarti jadwal ditolak; error lain adalah error database.
+func validateAppointmentSlot(ctx context.Context, dbpool querier, c slotCheck, cfg *config.Config) error {
+	slotDuration := cfg.SlotDuration
+	loc, err := doctorLocation(ctx, dbpool, c.DoctorID, cfg)
+	if err != nil {
+		return err
+	}
+	date := c.Date.In(loc)
+
+	// 1. Jam janji temu harus bulat sampai menit. Detik maupun pecahan detik
+	// (mis. 09:00:00.123456) ditolak, bukan dibulatkan, agar nilai yang
+	// tersimpan persis sama dengan yang dikirim client dan perbandingan slot stabil.
+	if date.Second() != 0 || date.Nanosecond() != 0 {
+		return &SlotError{http.StatusUnprocessableEntity, "Jam janji temu tidak boleh mengandung detik atau pecahan detik."}
+	}
+
+	// 2. Apakah klinik tutup (hari libur nasional) atau dokter libur pada tanggal tersebut?
+	holiday, err := isHoliday(ctx, dbpool, date, loc)
+	if err != nil {
+		return err
+	}
+	if holiday {
+		return &SlotError{http.StatusConflict, "Klinik tutup pada tanggal tersebut (hari libur nasional)."}
+	}
+	var isOff bool
+	err = dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctor_time_off WHERE doctor_id = $1 AND off_date = $2)", c.DoctorID, date.Format(dateLayout)).Scan(&isOff)
+	if err != nil {
+		return err
+	}
+	if isOff {
+		return &SlotError{http.StatusConflict, "Dokter tidak tersedia pada tanggal tersebut (libur)."}
+	}
+
+	// 3. Apakah sesuai dengan jadwal kerja mingguan dan grid slot dokter?
+	start, end, found, err := getWorkingHours(ctx, dbpool, c.DoctorID, isoWeekday(date))
+	if err != nil {
+		return err
+	}
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	offset := date.Sub(midnight)
+	inShift := found && offset >= start && offset <= end
+	if !inShift {
+		// Dini hari bisa termasuk shift malam yang dimulai kemarin
+		prevStart, prevEnd, prevFound, err := getWorkingHours(ctx, dbpool, c.DoctorID, isoWeekday(midnight.AddDate(0, 0, -1)))
+		if err != nil {
+			return err
+		}
+		if prevFound && prevEnd > 24*time.Hour && offset+24*time.Hour <= prevEnd {
+			inShift = true
+			start = prevStart - 24*time.Hour
+		}
+	}
+	if !inShift {
+		return &SlotError{http.StatusConflict, "Jadwal yang diminta di luar jam kerja dokter."}
+	}
+	if slotDuration > 0 && (offset-start)%slotDuration != 0 {
+		return &SlotError{http.StatusUnprocessableEntity, fmt.Sprintf("Jam janji temu harus mengikuti slot %s dari jam mulai praktik dokter.", slotDuration)}
+	}
+
+	// 4. Apakah pasien sudah punya janji temu lain di waktu yang bertumpuk?
+	var overlaps bool
+	err = dbpool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM appointments
+                  WHERE patient_id = $1 AND id != $2
+                  AND appointment_date > $3 AND appointment_date < $4
+                  AND `+activeAppointmentCondition+`)`,
+		c.PatientID, c.ExcludeID, c.Date.Add(-slotDuration), c.Date.Add(slotDuration)).Scan(&overlaps)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return &SlotError{http.StatusConflict, "Pasien sudah memiliki janji temu lain pada waktu tersebut."}
+	}
+
+	// 5. Apakah ada cukup jeda dengan janji temu dokter lainnya?
+	buffer, err := doctorBuffer(ctx, dbpool, c.DoctorID)
+	if err != nil {
+		return err
+	}
+	if buffer > 0 {
+		var tooClose bool
+		err = dbpool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM appointments
+                  WHERE doctor_id = $1 AND id != $2
+                  AND appointment_date > $3 AND appointment_date < $4
+                  AND `+activeAppointmentCondition+`)`,
+			c.DoctorID, c.ExcludeID, c.Date.Add(-slotDuration-buffer), c.Date.Add(slotDuration+buffer)).Scan(&tooClose)
+		if err != nil {
+			return err
+		}
+		if tooClose {
+			return &SlotError{http.StatusConflict, fmt.Sprintf("Dokter membutuhkan jeda %d menit antar pasien; jadwal terlalu dekat dengan janji temu lain.", int(buffer.Minutes()))}
+		}
+	}
+
+	return nil
+}
+
+// checkPatientDailyLimit menolak booking jika pasien sudah mencapai
+// cfg.MaxAppointmentsPerPatientPerDay janji temu aktif pada tanggal tersebut,
+// di semua dokter. Tanggal dihitung menurut zona waktu dokter yang dipesan,
+// sama seperti aturan jadwal lainnya (lihat doctorLocation).
+func checkPatientDailyLimit(ctx context.Context, dbpool querier, patientID, doctorID int, date time.Time, cfg *config.Config) error {
+	if cfg.MaxAppointmentsPerPatientPerDay <= 0 {
+		return nil
+	}
+	loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+	if err != nil {
+		return err
+	}
+	date = date.In(loc)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	var count int
+	err = dbpool.QueryRow(ctx, `SELECT COUNT(*) FROM appointments
+                  WHERE patient_id = $1 AND appointment_date >= $2 AND appointment_date < $3
+                  AND `+activeAppointmentCondition, patientID, day, day.AddDate(0, 0, 1)).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= cfg.MaxAppointmentsPerPatientPerDay {
+		return &SlotError{http.StatusConflict, fmt.Sprintf("Pasien sudah memiliki %d janji temu pada tanggal tersebut (maksimal %d per hari).", count, cfg.MaxAppointmentsPerPatientPerDay)}
+	}
+	return nil
+}
+
+// writeSlotError mengirim response untuk error dari validateAppointmentSlot.
+func writeSlotError(w http.ResponseWriter, err error) {
+	var slotErr *SlotError
+	if errors.As(err, &slotErr) {
+		http.Error(w, slotErr.Message, slotErr.Status)
+		return
+	}
+	log.Printf("Gagal memvalidasi jadwal janji temu: %v", err)
+	http.Error(w, "Gagal memvalidasi jadwal janji temu", http.StatusInternalServerError)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slotErrorStatus mengembalikan status HTTP dari err jika berupa *SlotError,
+// 0 jika err nil, dan -1 untuk error lain.
+func slotErrorStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var slotErr *SlotError
+	if errors.As(err, &slotErr) {
+		return slotErr.Status
+	}
+	return -1
+}
+
+func TestValidateAppointmentSlotRequiresGridAlignment(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+
+	tests := []struct {
+		name string
+		date time.Time
+		want int
+	}{
+		{"tepat di awal praktik", tomorrowAt(cfg, 8), 0},
+		{"sejajar dengan slot 30 menit", tomorrowAt(cfg, 9).Add(30 * time.Minute), 0},
+		{"di tengah slot", tomorrowAt(cfg, 9).Add(15 * time.Minute), http.StatusUnprocessableEntity},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAppointmentSlot(context.Background(), pool, slotCheck{DoctorID: doctorID, PatientID: patientID, Date: tc.date}, cfg)
+			if got := slotErrorStatus(err); got != tc.want {
+				t.Errorf("status = %d, want %d (err: %v)", got, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateAppointmentSlotUsesDoctorTimezone(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// 12:00 UTC adalah 12:00 bagi dokter UTC, tetapi 19:00 bagi dokter UTC+7
+	// yang sudah di luar jam praktik 08:00-16:00.
+	date := tomorrowAt(cfg, 12)
+	patientID := createTestPatient(t, pool)
+
+	tests := []struct {
+		timezone string
+		want     int
+	}{
+		{timezone: "UTC", want: 0},
+		{timezone: "Asia/Jakarta", want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		doctorID := createTestDoctor(t, pool)
+		execSQL(t, pool, "UPDATE doctors SET timezone = $1 WHERE id = $2", tt.timezone, doctorID)
+		err := validateAppointmentSlot(context.Background(), pool, slotCheck{DoctorID: doctorID, PatientID: patientID, Date: date}, cfg)
+		if got := slotErrorStatus(err); got != tt.want {
+			t.Errorf("dokter %s: status = %d, want %d (err: %v)", tt.timezone, got, tt.want, err)
+		}
+	}
+}
+
+func TestValidateAppointmentSlotEnforcesDoctorBuffer(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	patientID := createTestPatient(t, pool)
+	// Janji temu lain pukul 09:00-09:30; slot 09:30 bersebelahan langsung.
+	existing := tomorrowAt(cfg, 9)
+	adjacent := existing.Add(cfg.SlotDuration)
+
+	tests := []struct {
+		name          string
+		bufferMinutes int
+		date          time.Time
+		want          int
+	}{
+		{"tanpa jeda, slot bersebelahan", 0, adjacent, 0},
+		{"jeda 15 menit, slot bersebelahan", 15, adjacent, http.StatusConflict},
+		{"jeda 15 menit, slot berikutnya", 15, adjacent.Add(cfg.SlotDuration), 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doctorID := createTestDoctor(t, pool)
+			execSQL(t, pool, "UPDATE doctors SET buffer_minutes = $1 WHERE id = $2", tc.bufferMinutes, doctorID)
+			insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, existing, StatusConfirmed)
+
+			err := validateAppointmentSlot(context.Background(), pool, slotCheck{DoctorID: doctorID, PatientID: patientID, Date: tc.date}, cfg)
+			if got := slotErrorStatus(err); got != tc.want {
+				t.Errorf("status = %d, want %d (err: %v)", got, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestSubMinutePrecisionRejected(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	existing := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	create := CreateAppointmentHandler(pool, cfg)
+	reschedule := routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg))
+	const want = "Jam janji temu tidak boleh mengandung detik atau pecahan detik."
+
+	for _, tt := range []struct {
+		name string
+		date time.Time
+	}{
+		{"detik", tomorrowAt(cfg, 10).Add(30 * time.Second)},
+		{"pecahan detik", tomorrowAt(cfg, 10).Add(123456 * time.Microsecond)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(t, create, http.MethodPost, "/appointments", map[string]any{
+				"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": tt.date,
+			})
+			if rec.Code != http.StatusUnprocessableEntity || strings.TrimSpace(rec.Body.String()) != want {
+				t.Errorf("booking: status = %d (%s), want 422 %q", rec.Code, rec.Body, want)
+			}
+
+			rec = serveJSON(t, reschedule, http.MethodPatch, fmt.Sprintf("/appointments/%d", existing), RescheduleRequest{NewAppointmentDate: tt.date})
+			if rec.Code != http.StatusUnprocessableEntity || strings.TrimSpace(rec.Body.String()) != want {
+				t.Errorf("jadwal ulang: status = %d (%s), want 422 %q", rec.Code, rec.Body, want)
+			}
+		})
+	}
+}
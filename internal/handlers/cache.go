@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// DoctorCache menyimpan daftar dokter di memori selama ttl, karena data dokter
+// jarang berubah. Cache dikosongkan setiap kali data dokter diubah.
+// TTL 0 berarti cache dinonaktifkan.
+type DoctorCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	doctors   []Doctor
+	expiresAt time.Time
+	// version naik setiap Invalidate, agar Set dari pembaca yang memuat data
+	// sebelum perubahan tidak menyimpan data lama ke cache.
+	version uint64
+}
+
+// NewDoctorCache membuat cache daftar dokter dengan masa berlaku ttl.
+func NewDoctorCache(ttl time.Duration) *DoctorCache {
+	return &DoctorCache{ttl: ttl}
+}
+
+// Get mengembalikan salinan daftar dokter jika cache masih berlaku. Jika
+// tidak, version dipakai untuk Set setelah daftar dokter dimuat ulang dari
+// database. Hit/miss tidak dihitung saat cache dinonaktifkan.
+func (c *DoctorCache) Get() (doctors []Doctor, version uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 {
+		return nil, c.version, false
+	}
+	if c.doctors == nil || time.Now().After(c.expiresAt) {
+		doctorCacheMisses.Add(1)
+		return nil, c.version, false
+	}
+	doctorCacheHits.Add(1)
+	return append([]Doctor(nil), c.doctors...), c.version, true
+}
+
+// Set menyimpan daftar dokter ke cache, kecuali cache sudah di-Invalidate
+// sejak version diambil dari Get (daftar dokternya mungkin sudah usang).
+func (c *DoctorCache) Set(version uint64, doctors []Doctor) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		return
+	}
+	c.doctors = append([]Doctor{}, doctors...)
+	c.expiresAt = time.Now().Add(c.ttl)
+}
+
+// Invalidate mengosongkan cache, dipanggil setelah data dokter berubah.
+func (c *DoctorCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.doctors = nil
+	c.version++
+}
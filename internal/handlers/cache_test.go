@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// cacheStats mengembalikan jumlah hit dan miss cache dokter saat ini.
+func cacheStats() (hits, misses int64) {
+	return doctorCacheHits.Value(), doctorCacheMisses.Value()
+}
+
+func TestDoctorCacheHitAndMiss(t *testing.T) {
+	cache := NewDoctorCache(time.Minute)
+	hits, misses := cacheStats()
+
+	_, version, ok := cache.Get()
+	if ok {
+		t.Fatal("Get pada cache kosong seharusnya miss")
+	}
+	cache.Set(version, []Doctor{{ID: 1, Name: "Dr. Andi"}})
+	doctors, _, ok := cache.Get()
+	if !ok || len(doctors) != 1 || doctors[0].Name != "Dr. Andi" {
+		t.Fatalf("Get setelah Set = %+v, %v; want 1 dokter", doctors, ok)
+	}
+
+	gotHits, gotMisses := cacheStats()
+	if gotHits-hits != 1 || gotMisses-misses != 1 {
+		t.Fatalf("hit/miss bertambah %d/%d, want 1/1", gotHits-hits, gotMisses-misses)
+	}
+}
+
+func TestDoctorCacheIgnoresSetAfterInvalidate(t *testing.T) {
+	cache := NewDoctorCache(time.Minute)
+
+	// Pembaca mengambil version, lalu data dokter berubah sebelum ia Set.
+	_, staleVersion, _ := cache.Get()
+	cache.Invalidate()
+	cache.Set(staleVersion, []Doctor{{ID: 1, Name: "Data Lama"}})
+
+	if doctors, _, ok := cache.Get(); ok {
+		t.Fatalf("Set dengan version usang tetap tersimpan: %+v", doctors)
+	}
+}
+
+func TestDoctorCacheDisabledRecordsNoStats(t *testing.T) {
+	cache := NewDoctorCache(0)
+	hits, misses := cacheStats()
+
+	_, version, ok := cache.Get()
+	cache.Set(version, []Doctor{{ID: 1}})
+	_, _, ok2 := cache.Get()
+	if ok || ok2 {
+		t.Fatal("cache dengan TTL 0 seharusnya tidak pernah hit")
+	}
+	if gotHits, gotMisses := cacheStats(); gotHits != hits || gotMisses != misses {
+		t.Fatalf("hit/miss bertambah %d/%d saat cache nonaktif", gotHits-hits, gotMisses-misses)
+	}
+}
+
+func TestGetAllDoctorsServedFromCache(t *testing.T) {
+	cache := NewDoctorCache(time.Minute)
+	_, version, _ := cache.Get()
+	cache.Set(version, []Doctor{{ID: 1, Name: "Dr. Andi"}, {ID: 2, Name: "Dr. Budi"}})
+
+	// Pool nil: handler akan panic jika cache tidak dipakai.
+	handler := GetAllDoctorsHandler(nil, cache, testConfig())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/doctors", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var doctors []Doctor
+	if err := json.NewDecoder(rec.Body).Decode(&doctors); err != nil {
+		t.Fatalf("body tidak valid: %v", err)
+	}
+	if len(doctors) != 2 || doctors[1].Name != "Dr. Budi" {
+		t.Fatalf("doctors = %+v, want 2 dokter dari cache", doctors)
+	}
+}
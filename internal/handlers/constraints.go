@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueConstraintMessages memetakan nama unique constraint/index di database
+// ke pesan yang dikirim ke client saat terjadi unique_violation (23505).
+// Tambahkan entri di sini setiap kali membuat unique constraint baru.
+var uniqueConstraintMessages = map[string]string{
+	"patients_ktp_number_key":                    "Pasien dengan nomor KTP tersebut sudah terdaftar.",
+	"patients_ktp_number_dob_key":                "Pasien dengan nomor KTP dan tanggal lahir tersebut sudah terdaftar.",
+	"doctors_nik_key":                            "Dokter dengan NIK tersebut sudah terdaftar.",
+	"appointments_doctor_slot_unique":            "Slot waktu yang diminta sudah terisi. Silakan pilih jam lain.",
+	"doctor_schedules_doctor_id_day_of_week_key": "Jadwal untuk hari ini sudah ada.",
+	"doctor_time_off_doctor_id_off_date_key":     "Tanggal libur ini sudah terdaftar.",
+	"holidays_holiday_date_key":                  "Tanggal tersebut sudah terdaftar sebagai hari libur.",
+}
+
+// constraintMessage mengembalikan pesan untuk unique_violation pada pgErr
+// berdasarkan pgErr.ConstraintName, atau pesan umum jika constraint belum dipetakan.
+func constraintMessage(pgErr *pgconn.PgError) string {
+	if msg, ok := uniqueConstraintMessages[pgErr.ConstraintName]; ok {
+		return msg
+	}
+	return "Data tersebut sudah terdaftar."
+}
+
+// uniqueViolationMessage mengembalikan pesan untuk client jika err adalah
+// unique_violation (23505). ok bernilai false untuk error lain.
+func uniqueViolationMessage(err error) (msg string, ok bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return constraintMessage(pgErr), true
+	}
+	return "", false
+}
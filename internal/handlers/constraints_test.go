@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUniqueViolationMessage(t *testing.T) {
+	ktp := &pgconn.PgError{Code: "23505", ConstraintName: "patients_ktp_number_key"}
+	nik := &pgconn.PgError{Code: "23505", ConstraintName: "doctors_nik_key"}
+
+	ktpMsg, ok := uniqueViolationMessage(fmt.Errorf("insert pasien: %w", ktp))
+	if !ok {
+		t.Fatal("unique_violation KTP yang dibungkus tidak dikenali")
+	}
+	nikMsg, ok := uniqueViolationMessage(nik)
+	if !ok {
+		t.Fatal("unique_violation NIK tidak dikenali")
+	}
+	if ktpMsg != uniqueConstraintMessages[ktp.ConstraintName] || nikMsg != uniqueConstraintMessages[nik.ConstraintName] {
+		t.Errorf("pesan = %q, %q; want pesan sesuai constraint", ktpMsg, nikMsg)
+	}
+	if ktpMsg == nikMsg {
+		t.Errorf("constraint KTP dan NIK menghasilkan pesan yang sama: %q", ktpMsg)
+	}
+
+	if msg, _ := uniqueViolationMessage(&pgconn.PgError{Code: "23505", ConstraintName: "constraint_baru_key"}); msg != "Data tersebut sudah terdaftar." {
+		t.Errorf("constraint yang belum dipetakan: pesan = %q, want pesan umum", msg)
+	}
+	for _, err := range []error{&pgconn.PgError{Code: "23503", ConstraintName: "doctors_nik_key"}, errors.New("koneksi terputus")} {
+		if _, ok := uniqueViolationMessage(err); ok {
+			t.Errorf("%v dianggap unique_violation", err)
+		}
+	}
+}
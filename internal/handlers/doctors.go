@@ -0,0 +1,1156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DoctorAvailabilityResponse adalah data dokter beserta jumlah slot kosongnya.
+type DoctorAvailabilityResponse struct {
+	Doctor
+	OpenSlots int `json:"openSlots"`
+}
+
+// GetDoctorsAvailableTodayHandler mengembalikan dokter (opsional difilter dengan
+// ?specialty=) yang masih punya slot kosong hari ini, diurutkan dari jumlah
+// slot kosong terbanyak. Berguna untuk triase pasien walk-in.
+func GetDoctorsAvailableTodayHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		specialty := r.URL.Query().Get("specialty")
+
+		// 1. Ambil dokter sesuai spesialisasi (atau semua jika tidak diisi)
+		query := `SELECT ` + doctorColumns + ` FROM doctors
+                  WHERE $1 = '' OR ` + doctorHasSpecialty("doctors", "$1")
+
+		rows, err := dbpool.Query(r.Context(), query, specialty)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		var doctors []Doctor
+		for rows.Next() {
+			var d Doctor
+			if err := scanDoctor(rows, &d); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data dokter", http.StatusInternalServerError)
+				return
+			}
+			doctors = append(doctors, d)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 2. Hitung slot kosong yang tersisa hari ini untuk setiap dokter
+		now := time.Now().In(cfg.Location)
+		result := []DoctorAvailabilityResponse{}
+		for _, d := range doctors {
+			// "Hari ini" menurut zona waktu praktik dokter
+			loc, err := doctorLocation(r.Context(), dbpool, d.ID, cfg)
+			if err != nil {
+				log.Printf("Gagal mengambil zona waktu dokter %s: %v", maskIdentifier(d.ID), err)
+				http.Error(w, "Gagal menghitung ketersediaan dokter", http.StatusInternalServerError)
+				return
+			}
+			slots, err := computeOpenSlots(r.Context(), dbpool, d.ID, now.In(loc), cfg)
+			if err != nil {
+				log.Printf("Gagal menghitung ketersediaan dokter %s: %v", maskIdentifier(d.ID), err)
+				http.Error(w, "Gagal menghitung ketersediaan dokter", http.StatusInternalServerError)
+				return
+			}
+			remaining := 0
+			for _, s := range slots {
+				if s.After(now) {
+					remaining++
+				}
+			}
+			if remaining > 0 {
+				result = append(result, DoctorAvailabilityResponse{Doctor: d, OpenSlots: remaining})
+			}
+		}
+
+		// 3. Urutkan dari slot kosong terbanyak
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].OpenSlots > result[j].OpenSlots
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// GetDoctorsWorkingAtHandler mengembalikan dokter yang sedang praktik pada
+// waktu ?at= (RFC3339, default sekarang): jadwal mingguannya mencakup waktu
+// tersebut (termasuk shift malam yang dimulai kemarin), dokter tidak sedang
+// libur, dan klinik tidak tutup. Dipakai untuk mengarahkan pasien gawat darurat.
+func GetDoctorsWorkingAtHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi waktu yang diminta
+		at := time.Now()
+		if v := r.URL.Query().Get("at"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "Format at tidak valid, harus RFC3339 (mis. 2024-05-01T09:00:00+07:00)", http.StatusBadRequest)
+				return
+			}
+			at = parsed
+		}
+
+		// 2. Ambil semua dokter
+		rows, err := dbpool.Query(r.Context(), "SELECT "+doctorColumns+" FROM doctors ORDER BY id")
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		var doctors []Doctor
+		for rows.Next() {
+			var d Doctor
+			if err := scanDoctor(rows, &d); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data dokter", http.StatusInternalServerError)
+				return
+			}
+			doctors = append(doctors, d)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Saring dokter yang sedang praktik menurut zona waktunya masing-masing
+		result := []Doctor{}
+		for _, d := range doctors {
+			loc, err := doctorLocation(r.Context(), dbpool, d.ID, cfg)
+			if err != nil {
+				http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+				return
+			}
+			working, err := doctorWorkingAt(r.Context(), dbpool, d.ID, at.In(loc))
+			if err != nil {
+				log.Printf("Gagal memeriksa jadwal dokter %s: %v", maskIdentifier(d.ID), err)
+				http.Error(w, "Gagal memeriksa jadwal dokter", http.StatusInternalServerError)
+				return
+			}
+			if working {
+				result = append(result, d)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// doctorWorkingAt memeriksa apakah dokter sedang praktik pada waktu at, yang
+// sudah dikonversi ke zona waktu praktik dokter. Jam selesai praktik bersifat
+// eksklusif. Libur dokter dan hari libur klinik dicek pada tanggal shift dimulai.
+func doctorWorkingAt(ctx context.Context, dbpool querier, doctorID int, at time.Time) (bool, error) {
+	loc := at.Location()
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+	offset := at.Sub(midnight)
+
+	// 1. Shift hari ini, atau shift malam yang dimulai kemarin
+	shiftDay := midnight
+	start, end, found, err := getWorkingHours(ctx, dbpool, doctorID, isoWeekday(midnight))
+	if err != nil {
+		return false, err
+	}
+	working := found && offset >= start && offset < end
+	if !working {
+		prevDay := midnight.AddDate(0, 0, -1)
+		_, prevEnd, prevFound, err := getWorkingHours(ctx, dbpool, doctorID, isoWeekday(prevDay))
+		if err != nil {
+			return false, err
+		}
+		if prevFound && prevEnd > 24*time.Hour && offset+24*time.Hour < prevEnd {
+			working = true
+			shiftDay = prevDay
+		}
+	}
+	if !working {
+		return false, nil
+	}
+
+	// 2. Klinik tutup atau dokter libur pada tanggal shift?
+	holiday, err := isHoliday(ctx, dbpool, shiftDay, loc)
+	if err != nil || holiday {
+		return false, err
+	}
+	var isOff bool
+	err = dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctor_time_off WHERE doctor_id = $1 AND off_date = $2)", doctorID, shiftDay.Format(dateLayout)).Scan(&isOff)
+	if err != nil {
+		return false, err
+	}
+	return !isOff, nil
+}
+
+// maxCapacityRangeDays adalah panjang maksimum rentang ?from=/?to= pada
+// GetDoctorCapacityHandler, karena slot dihitung satu per satu per hari.
+const maxCapacityRangeDays = 62
+
+// DayCapacity adalah jumlah slot seorang dokter pada satu tanggal.
+type DayCapacity struct {
+	Date   string `json:"date"`
+	Total  int    `json:"total"`
+	Booked int    `json:"booked"`
+	Open   int    `json:"open"`
+}
+
+// parseDayRange membaca ?from= dan ?to= (YYYY-MM-DD, inklusif, wajib) sebagai
+// tanggal di zona waktu loc, dan mengembalikan jumlah harinya (maksimal
+// maxCapacityRangeDays). Pesan error yang dikembalikan siap dikirim sebagai
+// response 400.
+func parseDayRange(r *http.Request, loc *time.Location) (DateRange, int, error) {
+	dr, err := parseDateRange(r, "from", "to", loc)
+	if err != nil {
+		return dr, 0, err
+	}
+	if dr.From == nil || dr.To == nil {
+		return dr, 0, errors.New("Parameter from dan to wajib diisi (YYYY-MM-DD)")
+	}
+	// Dibulatkan agar hari dengan pergantian DST (23 atau 25 jam) tetap terhitung satu hari
+	days := int((dr.To.Sub(*dr.From)+12*time.Hour)/(24*time.Hour)) + 1
+	if days > maxCapacityRangeDays {
+		return dr, 0, fmt.Errorf("Rentang tanggal maksimal %d hari", maxCapacityRangeDays)
+	}
+	return dr, days, nil
+}
+
+// GetDoctorCapacityHandler mengembalikan jumlah slot total, terisi, dan kosong
+// seorang dokter untuk setiap tanggal pada rentang ?from= sampai ?to=
+// (YYYY-MM-DD, inklusif, wajib, maksimal maxCapacityRangeDays hari).
+// Slot dihitung dari jadwal mingguan seperti computeOpenSlots; hari libur
+// klinik atau dokter bernilai 0 semua. Dipakai untuk perencanaan kapasitas.
+func GetDoctorCapacityHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dokter
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || doctorID <= 0 {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Pastikan dokter ada, lalu baca tanggal menurut zona waktu praktiknya
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		dr, days, err := parseDayRange(r, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 3. Hitung slot per tanggal
+		result := make([]DayCapacity, 0, days)
+		for i := 0; i < days; i++ {
+			date := dr.From.AddDate(0, 0, i)
+			all, open, err := computeDaySlots(ctx, dbpool, doctorID, date, cfg)
+			if err != nil {
+				log.Printf("Gagal menghitung kapasitas dokter %s: %v", maskIdentifier(doctorID), err)
+				http.Error(w, "Gagal menghitung kapasitas dokter", http.StatusInternalServerError)
+				return
+			}
+			result = append(result, DayCapacity{
+				Date:   date.Format(dateLayout),
+				Total:  len(all),
+				Booked: len(all) - len(open),
+				Open:   len(open),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// CalendarDay adalah garis besar ketersediaan dokter pada satu tanggal.
+type CalendarDay struct {
+	Date    string `json:"date"`
+	Working bool   `json:"working"`
+	// StartTime dan EndTime (HH:MM:SS) hanya diisi jika dokter praktik.
+	// Untuk shift malam, EndTime jatuh pada keesokan harinya.
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+	// Off berisi HOLIDAY (libur klinik) atau TIME_OFF (libur dokter) jika
+	// jadwal mingguan hari itu dibatalkan; Note berisi nama libur atau alasannya.
+	Off  string  `json:"off,omitempty"`
+	Note *string `json:"note,omitempty"`
+}
+
+// GetDoctorCalendarHandler mengembalikan jam kerja efektif seorang dokter
+// untuk setiap tanggal pada rentang ?from= sampai ?to= (GET
+// /doctors/{id}/calendar): jadwal mingguan dikurangi hari libur klinik dan
+// libur dokter, tanpa rincian per slot. Rentang maksimal maxCapacityRangeDays.
+func GetDoctorCalendarHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dokter
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || doctorID <= 0 {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Pastikan dokter ada, lalu baca tanggal menurut zona waktu praktiknya
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		dr, days, err := parseDayRange(r, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, to := dr.From.Format(dateLayout), dr.To.Format(dateLayout)
+
+		// 3. Ambil jadwal mingguan, libur klinik, dan libur dokter pada rentang ini
+		type workingHours struct{ start, end pgtype.Time }
+		schedules := map[int]workingHours{}
+		offDays := map[string]CalendarDay{}
+		err = withRetry(r.Context(), func() error {
+			clear(schedules)
+			clear(offDays)
+			rows, err := dbpool.Query(ctx, "SELECT day_of_week, start_time, end_time FROM doctor_schedules WHERE doctor_id = $1", doctorID)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var day int
+				var h workingHours
+				if err := rows.Scan(&day, &h.start, &h.end); err != nil {
+					rows.Close()
+					return err
+				}
+				schedules[day] = h
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			// Libur klinik didahulukan jika tanggalnya sama dengan libur dokter
+			rows, err = dbpool.Query(ctx, `SELECT to_char(off_date, 'YYYY-MM-DD'), 'TIME_OFF', reason FROM doctor_time_off
+                  WHERE doctor_id = $1 AND off_date BETWEEN $2 AND $3
+                  UNION ALL
+                  SELECT to_char(holiday_date, 'YYYY-MM-DD'), 'HOLIDAY', name FROM holidays
+                  WHERE holiday_date BETWEEN $2 AND $3`, doctorID, from, to)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var day CalendarDay
+				if err := rows.Scan(&day.Date, &day.Off, &day.Note); err != nil {
+					return err
+				}
+				if prev, ok := offDays[day.Date]; !ok || prev.Off != "HOLIDAY" {
+					offDays[day.Date] = day
+				}
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil jadwal dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Susun kalender per tanggal
+		result := make([]CalendarDay, 0, days)
+		for i := 0; i < days; i++ {
+			date := dr.From.AddDate(0, 0, i)
+			day := CalendarDay{Date: date.Format(dateLayout)}
+			h, found := schedules[isoWeekday(date)]
+			if off, ok := offDays[day.Date]; ok {
+				day = off
+			} else if found {
+				day.Working = true
+				day.StartTime = formatClock(h.start)
+				day.EndTime = formatClock(h.end)
+			}
+			result = append(result, day)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// ExportDoctorAppointmentsHandler mengekspor janji temu seorang dokter sebagai
+// file CSV yang bisa diunduh/dicetak. Filter opsional ?from= dan ?to=
+// (YYYY-MM-DD, inklusif). Saat ini hanya ?format=csv yang didukung.
+func ExportDoctorAppointmentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID dokter, format, dan rentang tanggal
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" {
+			http.Error(w, "Format export yang didukung saat ini hanya csv.", http.StatusBadRequest)
+			return
+		}
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 2. Pastikan dokter ada
+		var exists bool
+		err = dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		// 3. Ambil janji temu beserta nama pasien, urut dari yang paling awal
+		query := `SELECT a.id, a.appointment_date, p.id, p.full_name, a.status
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  WHERE a.doctor_id = $1
+                  AND ($2::timestamptz IS NULL OR a.appointment_date >= $2)
+                  AND ($3::timestamptz IS NULL OR a.appointment_date < $3)
+                  ORDER BY a.appointment_date ASC, a.id ASC`
+
+		var to *time.Time
+		if dr.To != nil {
+			end := dr.To.AddDate(0, 0, 1)
+			to = &end
+		}
+
+		rows, err := dbpool.Query(r.Context(), query, doctorID, dr.From, to)
+		if err != nil {
+			log.Printf("Gagal mengambil janji temu dokter %s untuk export: %v", maskIdentifier(doctorID), err)
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		var records [][]string
+		for rows.Next() {
+			var apptID, patientID int
+			var apptDate time.Time
+			var patientName, status string
+			if err := rows.Scan(&apptID, &apptDate, &patientID, &patientName, &status); err != nil {
+				http.Error(w, "Gagal memindai data janji temu", http.StatusInternalServerError)
+				return
+			}
+			records = append(records, []string{
+				strconv.Itoa(apptID),
+				formatTimestamp(apptDate, cfg.Location),
+				strconv.Itoa(patientID),
+				patientName,
+				status,
+			})
+		}
+		if err := rows.Err(); err != nil {
+			log.Printf("Gagal mengambil janji temu dokter %s untuk export: %v", maskIdentifier(doctorID), err)
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Tulis CSV sebagai file unduhan
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="doctor-%d-appointments.csv"`, doctorID))
+
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"appointmentId", "appointmentDate", "patientId", "patientName", "status"})
+		cw.WriteAll(records)
+		if err := cw.Error(); err != nil {
+			log.Printf("Gagal menulis CSV: %v", err)
+		}
+	}
+}
+
+// UpdateDoctorHandler memperbarui data dokter (PUT /doctors/{id}, body lengkap).
+// Jika cfg.AuditSpecialtyChanges aktif dan spesialisasi berubah, perubahan
+// dicatat di doctor_specialty_audit dalam transaksi yang sama dan event
+// doctor.specialty_changed diterbitkan setelah commit.
+func UpdateDoctorHandler(dbpool *pgxpool.Pool, cache *DoctorCache, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dan body
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		var d Doctor
+		if err := decodeTolerantJSON(r.Body, &d); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if err := validateDoctor(&d, cfg.DoctorNIKRequired); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		d.ID = doctorID
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		// 2. Kunci baris dokter dan ambil spesialisasi lama
+		var oldSpecialty string
+		err = tx.QueryRow(ctx, "SELECT specialty FROM doctors WHERE id = $1 FOR UPDATE", doctorID).Scan(&oldSpecialty)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Update data dokter
+		_, err = tx.Exec(ctx, "UPDATE doctors SET nik = NULLIF($1, ''), name = $2, specialty = $3, timezone = $4, reminder_lead_time = $5, buffer_minutes = $6 WHERE id = $7",
+			d.NIK, d.Name, d.Specialty, d.Timezone, reminderLeadDuration(d.ReminderLeadTime), d.BufferMinutes, doctorID)
+		if err != nil {
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal memperbarui dokter: %v", err)
+			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		if err := saveDoctorSpecialties(ctx, tx, doctorID, d.Specialties); err != nil {
+			log.Printf("Gagal menyimpan spesialisasi dokter: %v", err)
+			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Catat perubahan spesialisasi utama (opsional)
+		specialtyChanged := cfg.AuditSpecialtyChanges && oldSpecialty != d.Specialty
+		if specialtyChanged {
+			_, err = tx.Exec(ctx, `INSERT INTO doctor_specialty_audit (doctor_id, old_specialty, new_specialty)
+                      VALUES ($1, $2, $3)`, doctorID, oldSpecialty, d.Specialty)
+			if err != nil {
+				log.Printf("Gagal mencatat audit spesialisasi dokter: %v", err)
+				http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit perubahan dokter: %v", err)
+			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// Daftar dokter berubah, kosongkan cache
+		cache.Invalidate()
+
+		if specialtyChanged {
+			publishEvent("doctor.specialty_changed", map[string]any{
+				"doctorId":     doctorID,
+				"oldSpecialty": oldSpecialty,
+				"newSpecialty": d.Specialty,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d)
+	}
+}
+
+// SlotCheckResponse adalah hasil pengecekan satu slot.
+type SlotCheckResponse struct {
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// SlotCheckHandler memeriksa apakah satu slot dokter (?at=RFC3339) masih bisa
+// dibooking, dengan validasi yang sama seperti pembuatan janji temu tetapi
+// tanpa menyimpan apa pun. ?patientId= opsional untuk ikut mengecek bentrok
+// jadwal pasien. Dipakai layar booking untuk mengecek ulang sebelum submit.
+func SlotCheckHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi parameter
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+		if err != nil {
+			http.Error(w, "Parameter at wajib diisi dengan format RFC3339", http.StatusBadRequest)
+			return
+		}
+		patientID := 0
+		if v := r.URL.Query().Get("patientId"); v != "" {
+			if patientID, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "patientId tidak valid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// 2. Pastikan dokter ada
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		// 3. Jalankan validasi jadwal yang sama dengan pembuatan janji temu
+		resp, err := checkSlotAvailability(ctx, dbpool, slotCheck{DoctorID: doctorID, PatientID: patientID, Date: at}, cfg)
+		if err != nil {
+			writeSlotError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// checkSlotAvailability menjalankan validateAppointmentSlot lalu mengecek
+// apakah slot dokter sudah terisi, tanpa menyimpan apa pun. Alasan penolakan
+// dikembalikan di SlotCheckResponse; error hanya untuk kegagalan database.
+func checkSlotAvailability(ctx context.Context, dbpool querier, c slotCheck, cfg *config.Config) (SlotCheckResponse, error) {
+	err := validateAppointmentSlot(ctx, dbpool, c, cfg)
+	var slotErr *SlotError
+	if errors.As(err, &slotErr) {
+		return SlotCheckResponse{Available: false, Reason: slotErr.Message}, nil
+	}
+	if err != nil {
+		return SlotCheckResponse{}, err
+	}
+
+	// Slot dokter sudah terisi? (saat booking dijaga oleh unique index)
+	var taken bool
+	err = dbpool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM appointments
+                  WHERE doctor_id = $1 AND appointment_date = $2 AND `+activeAppointmentCondition+`)`, c.DoctorID, c.Date).Scan(&taken)
+	if err != nil {
+		return SlotCheckResponse{}, err
+	}
+	if taken {
+		return SlotCheckResponse{Available: false, Reason: uniqueConstraintMessages["appointments_doctor_slot_unique"]}, nil
+	}
+	return SlotCheckResponse{Available: true}, nil
+}
+
+// GetDoctorPatientsHandler mengembalikan daftar pasien (tanpa duplikat) yang
+// pernah punya janji temu dengan seorang dokter, dengan pagination.
+// Filter opsional ?status= hanya menghitung janji temu dengan status tersebut
+// (mis. CHECKED_IN untuk pasien yang benar-benar sudah datang). Pasien
+// nonaktif tidak ditampilkan kecuali dengan ?includeInactive=true.
+func GetDoctorPatientsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi parameter
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		limit, offset, err := parsePagination(r, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status := strings.ToUpper(r.URL.Query().Get("status"))
+		if status != "" && !isKnownStatus(status) {
+			http.Error(w, "status tidak dikenal", http.StatusBadRequest)
+			return
+		}
+
+		// Pasien nonaktif hanya ikut jika ?includeInactive=true
+		includeInactive := r.URL.Query().Get("includeInactive") == "true"
+
+		// 2. Ambil pasien unik lewat tabel appointments
+		query := `SELECT ` + qualifiedPatientColumns("p") + `
+                  FROM patients p
+                  WHERE EXISTS (SELECT 1 FROM appointments a
+                                WHERE a.patient_id = p.id AND a.doctor_id = $1
+                                AND ($2 = '' OR a.status = $2))
+                  AND ($5 OR p.active)
+                  ORDER BY p.full_name, p.id
+                  LIMIT $3 OFFSET $4`
+
+		countQuery := `SELECT COUNT(DISTINCT a.patient_id) FROM appointments a
+                       JOIN patients p ON a.patient_id = p.id
+                       WHERE a.doctor_id = $1 AND ($2 = '' OR a.status = $2)
+                       AND ($3 OR p.active)`
+
+		patients := []Patient{}
+		var total int
+		err = withRetry(r.Context(), func() error {
+			patients = patients[:0]
+			if err := dbpool.QueryRow(r.Context(), countQuery, doctorID, status, includeInactive).Scan(&total); err != nil {
+				return err
+			}
+			rows, err := dbpool.Query(r.Context(), query, doctorID, status, limit, offset, includeInactive)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var p Patient
+				if err := scanPatient(rows, &p); err != nil {
+					return err
+				}
+				patients = append(patients, p)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		setPaginationHeaders(w, r, total, limit, offset)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(patients)
+	}
+}
+
+// GetDoctorByNIKHandler mencari satu dokter berdasarkan NIK
+// (GET /doctors/by-nik?nik=...), sama seperti pencarian pasien berdasarkan KTP.
+// Path /doctors/by-nik/{nik} tidak dipakai karena bentrok dengan /doctors/{id}/... di router.
+func GetDoctorByNIKHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nik := strings.TrimSpace(r.URL.Query().Get("nik"))
+		if len(nik) != 10 || !digitsOnly.MatchString(nik) {
+			http.Error(w, "NIK dokter harus 10 digit angka", http.StatusBadRequest)
+			return
+		}
+
+		var d Doctor
+		err := withRetry(r.Context(), func() error {
+			return scanDoctor(dbpool.QueryRow(r.Context(), "SELECT "+doctorColumns+" FROM doctors WHERE nik = $1", nik), &d)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d)
+	}
+}
+
+// BookingOption adalah seorang dokter beserta slot kosongnya pada satu tanggal.
+type BookingOption struct {
+	Doctor
+	OpenSlots []Timestamp `json:"openSlots"`
+}
+
+// GetBookingOptionsHandler menggabungkan daftar dokter dan perhitungan slot
+// kosong dalam satu response untuk alur booking terpandu:
+// GET /booking/options?specialty=&date=YYYY-MM-DD. specialty opsional;
+// date wajib dan tidak boleh di masa lalu. Untuk hari ini, slot yang sudah
+// lewat tidak ditampilkan. Dokter tanpa slot kosong tetap ikut dengan openSlots [].
+func GetBookingOptionsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi parameter
+		specialty := strings.TrimSpace(r.URL.Query().Get("specialty"))
+		date, err := time.ParseInLocation(dateLayout, r.URL.Query().Get("date"), cfg.Location)
+		if err != nil {
+			http.Error(w, "Parameter date wajib diisi dengan format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		now := time.Now().In(cfg.Location)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, cfg.Location)
+		if date.Before(today) {
+			http.Error(w, "Tanggal booking tidak boleh di masa lalu", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil dokter sesuai spesialisasi (atau semua jika tidak diisi)
+		rows, err := dbpool.Query(ctx, `SELECT `+doctorColumns+` FROM doctors
+                  WHERE $1 = '' OR `+doctorHasSpecialty("doctors", "$1")+`
+                  ORDER BY name, id`, specialty)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		var doctors []Doctor
+		for rows.Next() {
+			var d Doctor
+			if err := scanDoctor(rows, &d); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data dokter", http.StatusInternalServerError)
+				return
+			}
+			doctors = append(doctors, d)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Hitung slot kosong setiap dokter pada tanggal tersebut
+		options := []BookingOption{}
+		for _, d := range doctors {
+			slots, err := computeOpenSlots(ctx, dbpool, d.ID, date, cfg)
+			if err != nil {
+				log.Printf("Gagal menghitung ketersediaan dokter %s: %v", maskIdentifier(d.ID), err)
+				http.Error(w, "Gagal menghitung ketersediaan dokter", http.StatusInternalServerError)
+				return
+			}
+			option := BookingOption{Doctor: d, OpenSlots: []Timestamp{}}
+			for _, s := range slots {
+				if s.After(now) {
+					option.OpenSlots = append(option.OpenSlots, Timestamp{s})
+				}
+			}
+			options = append(options, option)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(options)
+	}
+}
+
+// doctorBaseColumns adalah kolom tabel doctors yang dipindai ke struct Doctor.
+const doctorBaseColumns = "id, nik, name, specialty, timezone, reminder_lead_time, buffer_minutes"
+
+// doctorColumns adalah daftar kolom standar untuk dipindai ke struct Doctor
+// lewat scanDoctor, untuk query FROM doctors tanpa alias. Urutannya harus
+// sama dengan urutan Scan di bawah.
+var doctorColumns = doctorBaseColumns + ", " + doctorSpecialtiesColumn("doctors")
+
+// qualifiedDoctorColumns mengembalikan doctorColumns dengan prefix alias tabel.
+func qualifiedDoctorColumns(alias string) string {
+	return qualifyColumns(alias, doctorBaseColumns) + ", " + doctorSpecialtiesColumn(alias)
+}
+
+// doctorSpecialtiesColumn adalah subquery yang mengumpulkan semua spesialisasi
+// dokter (tabel doctor_specialties) sebagai array, urut abjad.
+func doctorSpecialtiesColumn(alias string) string {
+	return "ARRAY(SELECT ds.specialty FROM doctor_specialties ds WHERE ds.doctor_id = " + alias + ".id ORDER BY ds.specialty)"
+}
+
+// doctorHasSpecialty adalah kondisi SQL yang bernilai true jika salah satu
+// spesialisasi dokter (alias tabel doctors) sama dengan param, tanpa
+// membedakan huruf besar/kecil.
+func doctorHasSpecialty(alias, param string) string {
+	return "EXISTS (SELECT 1 FROM doctor_specialties ds WHERE ds.doctor_id = " + alias + ".id AND LOWER(ds.specialty) = LOWER(" + param + "))"
+}
+
+// saveDoctorSpecialties mengganti seluruh spesialisasi dokter dengan specialties
+// (yang sudah dinormalkan oleh validateDoctor).
+func saveDoctorSpecialties(ctx context.Context, dbpool querier, doctorID int, specialties []string) error {
+	if _, err := dbpool.Exec(ctx, "DELETE FROM doctor_specialties WHERE doctor_id = $1", doctorID); err != nil {
+		return err
+	}
+	_, err := dbpool.Exec(ctx, `INSERT INTO doctor_specialties (doctor_id, specialty)
+                  SELECT $1, UNNEST($2::text[])`, doctorID, specialties)
+	return err
+}
+
+// scanDoctor memindai satu baris hasil SELECT doctorColumns.
+// Kolom tambahan (mis. hasil JOIN) bisa dipindai lewat extra, sesudah kolom standar.
+func scanDoctor(row pgx.Row, d *Doctor, extra ...any) error {
+	var nik *string
+	var lead *time.Duration
+	dest := []any{&d.ID, &nik, &d.Name, &d.Specialty, &d.Timezone, &lead, &d.BufferMinutes, &d.Specialties}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	d.NIK = ""
+	if nik != nil {
+		d.NIK = *nik
+	}
+	d.ReminderLeadTime = nil
+	if lead != nil {
+		s := lead.String()
+		d.ReminderLeadTime = &s
+	}
+	return nil
+}
+
+// reminderLeadDuration mengubah Doctor.ReminderLeadTime yang sudah lolos
+// validateDoctor menjadi nilai untuk kolom reminder_lead_time (nil = NULL).
+func reminderLeadDuration(s *string) *time.Duration {
+	if s == nil {
+		return nil
+	}
+	d, err := time.ParseDuration(*s)
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// doctorLocation mengembalikan zona waktu praktik dokter, atau zona waktu
+// aplikasi jika dokter tidak mengatur zona waktu sendiri.
+func doctorLocation(ctx context.Context, dbpool querier, doctorID int, cfg *config.Config) (*time.Location, error) {
+	var tz *string
+	err := dbpool.QueryRow(ctx, "SELECT timezone FROM doctors WHERE id = $1", doctorID).Scan(&tz)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && tz == nil) {
+		return cfg.Location, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Printf("Zona waktu dokter %s tidak valid (%q), memakai zona waktu aplikasi", maskIdentifier(doctorID), *tz)
+		return cfg.Location, nil
+	}
+	return loc, nil
+}
+
+// CurrentAppointmentResponse adalah janji temu beserta nama pasiennya, dipakai
+// untuk janji temu yang sedang berlangsung maupun daftar janji temu per tanggal.
+type CurrentAppointmentResponse struct {
+	Appointment
+	PatientName string `json:"patientName"`
+}
+
+// GetCurrentDoctorAppointmentHandler mengembalikan janji temu dokter yang sedang
+// berlangsung, yaitu yang rentang waktunya [appointmentDate, appointmentDate +
+// cfg.SlotDuration) mencakup saat ini dan berstatus CHECKED_IN, CONFIRMED, atau
+// RESCHEDULED. 404 jika dokter sedang tidak menangani pasien.
+// Dipakai papan ruang tunggu.
+func GetCurrentDoctorAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// Jika ada lebih dari satu (mis. pasien sebelumnya belum selesai),
+		// yang sudah check-in dan paling baru dimulai diutamakan.
+		query := `SELECT ` + qualifiedAppointmentColumns("a") + `, p.full_name
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  WHERE a.doctor_id = $1 AND a.status IN ($2, $3, $4)
+                  AND a.appointment_date <= NOW() AND a.appointment_date + $5::interval > NOW()
+                  ORDER BY (a.status = $2) DESC, a.appointment_date DESC
+                  LIMIT 1`
+
+		var resp CurrentAppointmentResponse
+		err = withRetry(r.Context(), func() error {
+			return scanAppointment(dbpool.QueryRow(r.Context(), query, doctorID, StatusCheckedIn, StatusConfirmed, StatusRescheduled, cfg.SlotDuration), &resp.Appointment, &resp.PatientName)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Dokter sedang tidak menangani janji temu", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// GetDoctorAppointmentsByDateHandler mengembalikan semua janji temu seorang
+// dokter pada satu tanggal (GET /doctors/{id}/appointments/by-date?date=YYYY-MM-DD)
+// beserta nama pasien, diurutkan menurut jam. Tanggal dibaca menurut zona
+// waktu praktik dokter. Janji temu yang dibatalkan tetap ditampilkan dengan
+// statusnya; slot yang masih di-hold tidak. Status check-in terlihat dari
+// status dan checkedInAt.
+func GetDoctorAppointmentsByDateHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dokter dan tanggal
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || doctorID <= 0 {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			http.Error(w, "Parameter date wajib diisi (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		date, err := time.Parse(dateLayout, dateStr)
+		if err != nil {
+			http.Error(w, "Format date harus YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Pastikan dokter ada dan tentukan awal hari menurut zona waktunya
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+		// 3. Ambil janji temu pada hari tersebut
+		query := `SELECT ` + qualifiedAppointmentColumns("a") + `, p.full_name
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  WHERE a.doctor_id = $1 AND a.appointment_date >= $2 AND a.appointment_date < $3
+                  AND a.status <> $4
+                  ORDER BY a.appointment_date, a.id`
+
+		var appointments []CurrentAppointmentResponse
+		err = withRetry(r.Context(), func() error {
+			appointments = []CurrentAppointmentResponse{}
+			rows, err := dbpool.Query(ctx, query, doctorID, day, day.AddDate(0, 0, 1), StatusHeld)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var appt CurrentAppointmentResponse
+				if err := scanAppointment(rows, &appt.Appointment, &appt.PatientName); err != nil {
+					return err
+				}
+				appointments = append(appointments, appt)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appointments)
+	}
+}
+
+// AppointmentConflict adalah sepasang janji temu dokter yang waktunya bertumpuk.
+type AppointmentConflict struct {
+	First  Appointment `json:"first"`
+	Second Appointment `json:"second"`
+}
+
+// GetDoctorConflictsHandler mencari pasangan janji temu aktif seorang dokter
+// (lihat activeAppointmentCondition) yang bertumpuk, yaitu dimulai kurang dari
+// cfg.SlotDuration satu sama lain (GET /doctors/{id}/conflicts). Data seperti
+// ini bisa berasal dari sistem lama sebelum ada pengecekan bentrok; endpoint
+// ini membantu admin merapikannya. Setiap pasangan dilaporkan sekali, First selalu yang lebih awal.
+func GetDoctorConflictsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || doctorID <= 0 {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		query := `WITH active AS (
+                      SELECT * FROM appointments WHERE doctor_id = $1 AND ` + activeAppointmentCondition + `
+                  )
+                  SELECT ` + qualifiedAppointmentColumns("a") + `, ` + qualifiedAppointmentColumns("b") + `
+                  FROM active a
+                  JOIN active b ON (b.appointment_date > a.appointment_date
+                            OR (b.appointment_date = a.appointment_date AND b.id > a.id))
+                       AND b.appointment_date < a.appointment_date + $2::interval
+                  ORDER BY a.appointment_date, a.id, b.appointment_date, b.id`
+
+		var conflicts []AppointmentConflict
+		err = withRetry(r.Context(), func() error {
+			conflicts = []AppointmentConflict{}
+			rows, err := dbpool.Query(ctx, query, doctorID, cfg.SlotDuration)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var c AppointmentConflict
+				if err := scanAppointment(rows, &c.First, appointmentScanDest(&c.Second)...); err != nil {
+					return err
+				}
+				conflicts = append(conflicts, c)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mencari janji temu yang bentrok", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(conflicts)
+	}
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetDoctorsAvailableTodaySkipsFullyBookedDoctor(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Sekarang pukul 10 di zona waktu kedua dokter (praktik 08:00-16:00)
+	loc := zoneWithLocalHour(t, 10)
+	open, full := createTestDoctor(t, pool), createTestDoctor(t, pool)
+	execSQL(t, pool, "UPDATE doctors SET timezone = $1 WHERE id IN ($2, $3)", loc.String(), open, full)
+	specialty := addTestSpecialty(t, pool, open, full)
+
+	// Semua slot dokter full hari ini sudah terisi
+	patientID := createTestPatient(t, pool)
+	now := time.Now().In(loc)
+	for slot := time.Date(now.Year(), now.Month(), now.Day(), 8, 0, 0, 0, loc); slot.Hour() < 16; slot = slot.Add(cfg.SlotDuration) {
+		insertTestAppointment(t, pool, patientID, full, slot, StatusConfirmed)
+	}
+
+	rec := serve(GetDoctorsAvailableTodayHandler(pool, cfg), httptest.NewRequest(http.MethodGet, "/doctors/available-today?specialty="+url.QueryEscape(specialty), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got := decodeJSON[[]DoctorAvailabilityResponse](t, rec)
+	if len(got) != 1 || got[0].ID != open {
+		t.Fatalf("dokter tersedia = %+v, want hanya dokter %d", got, open)
+	}
+	// Slot 10:30 sampai 15:30 masih kosong
+	if got[0].OpenSlots < 10 {
+		t.Errorf("openSlots = %d, want minimal 10", got[0].OpenSlots)
+	}
+}
+
+func TestExportDoctorAppointmentsCSV(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+
+	// Disimpan tidak berurutan; export harus urut dari jadwal paling awal
+	late := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusConfirmed)
+	early := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusCancelled)
+
+	handler := routed("GET /doctors/{id}/appointments/export", ExportDoctorAppointmentsHandler(pool, cfg))
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/doctors/%d/appointments/export", doctorID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV tidak valid: %v", err)
+	}
+	want := [][]string{
+		{"appointmentId", "appointmentDate", "patientId", "patientName", "status"},
+		{strconv.Itoa(early), formatTimestamp(tomorrowAt(cfg, 9), cfg.Location), strconv.Itoa(patientID), "Pasien Test", StatusCancelled},
+		{strconv.Itoa(late), formatTimestamp(tomorrowAt(cfg, 11), cfg.Location), strconv.Itoa(patientID), "Pasien Test", StatusConfirmed},
+	}
+	if !slices.EqualFunc(records, want, slices.Equal) {
+		t.Fatalf("CSV = %q, want %q", records, want)
+	}
+}
+
+func TestExportDoctorAppointmentsRejectsUnknownFormat(t *testing.T) {
+	// Format divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := routed("GET /doctors/{id}/appointments/export", ExportDoctorAppointmentsHandler(nil, testConfig()))
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/doctors/1/appointments/export?format=pdf", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestUpdateDoctorAuditsSpecialtyChange(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.AuditSpecialtyChanges = true
+	doctorID := createTestDoctor(t, pool)
+	var nik string
+	if err := pool.QueryRow(context.Background(), "SELECT nik FROM doctors WHERE id = $1", doctorID).Scan(&nik); err != nil {
+		t.Fatalf("Gagal mengambil NIK dokter test: %v", err)
+	}
+	handler := routed("PUT /doctors/{id}", UpdateDoctorHandler(pool, NewDoctorCache(cfg.DoctorCacheTTL), cfg))
+	target := fmt.Sprintf("/doctors/%d", doctorID)
+
+	update := func(specialty string) {
+		t.Helper()
+		rec := serveJSON(t, handler, http.MethodPut, target, map[string]any{"nik": nik, "name": "Dokter Test", "specialty": specialty})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update spesialisasi %s: status = %d, want 200 (%s)", specialty, rec.Code, rec.Body)
+		}
+	}
+	update("Anak")
+	// Spesialisasi yang sama tidak menambah baris audit.
+	update("Anak")
+
+	rows, err := pool.Query(context.Background(), "SELECT old_specialty, new_specialty FROM doctor_specialty_audit WHERE doctor_id = $1", doctorID)
+	if err != nil {
+		t.Fatalf("Gagal mengambil audit: %v", err)
+	}
+	var audits [][2]string
+	for rows.Next() {
+		var a [2]string
+		if err := rows.Scan(&a[0], &a[1]); err != nil {
+			t.Fatalf("Gagal membaca audit: %v", err)
+		}
+		audits = append(audits, a)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Gagal membaca audit: %v", err)
+	}
+	if want := [][2]string{{"Umum", "Anak"}}; !slices.Equal(audits, want) {
+		t.Errorf("audit = %v, want %v", audits, want)
+	}
+}
+
+func TestSlotCheckFreeAndTakenSlots(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	handler := routed("GET /doctors/{id}/slot-check", SlotCheckHandler(pool, cfg))
+
+	check := func(at time.Time) SlotCheckResponse {
+		t.Helper()
+		target := fmt.Sprintf("/doctors/%d/slot-check?at=%s", doctorID, url.QueryEscape(at.Format(time.RFC3339)))
+		rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", target, rec.Code, rec.Body)
+		}
+		return decodeJSON[SlotCheckResponse](t, rec)
+	}
+
+	if got := check(tomorrowAt(cfg, 10)); !got.Available {
+		t.Errorf("slot kosong: %+v, want available", got)
+	}
+	if got := check(tomorrowAt(cfg, 9)); got.Available || got.Reason == "" {
+		t.Errorf("slot terisi: %+v, want tidak tersedia dengan alasan", got)
+	}
+	if got := check(tomorrowAt(cfg, 20)); got.Available || got.Reason == "" {
+		t.Errorf("di luar jam praktik: %+v, want tidak tersedia dengan alasan", got)
+	}
+
+	// Tidak ada yang disimpan oleh pengecekan slot.
+	var count int
+	pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM appointments WHERE doctor_id = $1", doctorID).Scan(&count)
+	if count != 1 {
+		t.Errorf("jumlah janji temu dokter = %d, want 1", count)
+	}
+}
+
+func TestGetDoctorPatientsListsEachPatientOnce(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	regular := createTestPatient(t, pool)
+	once := createTestPatient(t, pool)
+	insertTestAppointment(t, pool, regular, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	insertTestAppointment(t, pool, regular, doctorID, tomorrowAt(cfg, 10), StatusCheckedIn)
+	insertTestAppointment(t, pool, regular, doctorID, tomorrowAt(cfg, 11), StatusCancelled)
+	insertTestAppointment(t, pool, once, doctorID, tomorrowAt(cfg, 12), StatusConfirmed)
+	handler := routed("GET /doctors/{id}/patients", GetDoctorPatientsHandler(pool, cfg))
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{regular, once}},
+		{"?status=CHECKED_IN", []int{regular}},
+	}
+	for _, tc := range tests {
+		rec := serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/doctors/%d/patients%s", doctorID, tc.query), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, want 200 (%s)", tc.query, rec.Code, rec.Body)
+		}
+		if total := rec.Header().Get("X-Total-Count"); total != strconv.Itoa(len(tc.want)) {
+			t.Errorf("%q: X-Total-Count = %s, want %d", tc.query, total, len(tc.want))
+		}
+		var ids []int
+		for _, p := range decodeJSON[[]Patient](t, rec) {
+			ids = append(ids, p.ID)
+		}
+		if !slices.Equal(ids, tc.want) {
+			t.Errorf("%q: pasien = %v, want %v", tc.query, ids, tc.want)
+		}
+	}
+}
+
+func TestGetDoctorByNIK(t *testing.T) {
+	pool := testPool(t, nil)
+	doctorID := createTestDoctor(t, pool)
+	var nik string
+	if err := pool.QueryRow(context.Background(), "SELECT nik FROM doctors WHERE id = $1", doctorID).Scan(&nik); err != nil {
+		t.Fatalf("Gagal mengambil NIK dokter test: %v", err)
+	}
+	handler := GetDoctorByNIKHandler(pool)
+
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/doctors/by-nik?nik="+nik, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("NIK terdaftar: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if d := decodeJSON[Doctor](t, rec); d.ID != doctorID || d.NIK != nik {
+		t.Errorf("dokter = %+v, want id %d dengan NIK %s", d, doctorID, nik)
+	}
+
+	// Setelah dokternya dihapus, NIK yang sama pasti tidak terdaftar.
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1", doctorID)
+	execSQL(t, pool, "DELETE FROM doctors WHERE id = $1", doctorID)
+	rec = serve(handler, httptest.NewRequest(http.MethodGet, "/doctors/by-nik?nik="+nik, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("NIK tidak terdaftar: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestGetDoctorByNIKRejectsMalformedNIK(t *testing.T) {
+	// NIK divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	for _, nik := range []string{"", "12345", "12345678901", "12345abcde"} {
+		rec := serve(GetDoctorByNIKHandler(nil), httptest.NewRequest(http.MethodGet, "/doctors/by-nik?nik="+nik, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("nik=%q: status = %d, want 400", nik, rec.Code)
+		}
+	}
+}
+
+func TestGetBookingOptionsWithPartialAvailability(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	free := createTestDoctor(t, pool)
+	partial := createTestDoctor(t, pool)
+	createTestDoctor(t, pool) // spesialisasi lain, tidak ikut
+	specialty := addTestSpecialty(t, pool, free, partial)
+	// Dokter kedua hanya praktik 08:00-10:00 dan slot 09:00 sudah terisi.
+	execSQL(t, pool, "UPDATE doctor_schedules SET end_time = '10:00' WHERE doctor_id = $1", partial)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), partial, tomorrowAt(cfg, 9), StatusConfirmed)
+
+	date := tomorrowAt(cfg, 0).Format(dateLayout)
+	target := fmt.Sprintf("/booking/options?specialty=%s&date=%s", url.QueryEscape(specialty), date)
+	rec := serve(GetBookingOptionsHandler(pool, cfg), httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	options := decodeJSON[[]BookingOption](t, rec)
+	if len(options) != 2 || options[0].ID != free || options[1].ID != partial {
+		t.Fatalf("dokter = %+v, want [%d %d]", options, free, partial)
+	}
+
+	if n := len(options[0].OpenSlots); n != 16 {
+		t.Errorf("dokter tanpa booking: %d slot kosong, want 16", n)
+	}
+	var got []time.Time
+	for _, s := range options[1].OpenSlots {
+		got = append(got, s.Time)
+	}
+	want := []time.Time{tomorrowAt(cfg, 8), tomorrowAt(cfg, 8).Add(30 * time.Minute), tomorrowAt(cfg, 9).Add(30 * time.Minute)}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("slot kosong dokter kedua = %v, want %v", got, want)
+	}
+}
+
+func TestGetBookingOptionsRejectsPastDate(t *testing.T) {
+	// Tanggal divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	cfg := testConfig()
+	for _, date := range []string{"", "15-01-2025", tomorrowAt(cfg, 0).AddDate(0, 0, -2).Format(dateLayout)} {
+		rec := serve(GetBookingOptionsHandler(nil, cfg), httptest.NewRequest(http.MethodGet, "/booking/options?date="+date, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("date=%q: status = %d, want 400", date, rec.Code)
+		}
+	}
+}
+
+func TestGetCurrentDoctorAppointment(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	now := time.Now().Truncate(time.Minute)
+	handler := routed("GET /doctors/{id}/appointments/current", GetCurrentDoctorAppointmentHandler(pool, cfg))
+	target := fmt.Sprintf("/doctors/%d/appointments/current", doctorID)
+
+	// Sudah selesai (slot 30 menit) dan belum dimulai: dokter sedang tidak menangani pasien.
+	insertTestAppointment(t, pool, patientID, doctorID, now.Add(-45*time.Minute), StatusCheckedIn)
+	insertTestAppointment(t, pool, patientID, doctorID, now.Add(10*time.Minute), StatusConfirmed)
+	if rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil)); rec.Code != http.StatusNotFound {
+		t.Fatalf("di luar janji temu: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+
+	current := insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, now.Add(-10*time.Minute), StatusCheckedIn)
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("di dalam janji temu: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if got := decodeJSON[CurrentAppointmentResponse](t, rec); got.ID != current || got.PatientName != "Pasien Test" {
+		t.Errorf("janji temu saat ini = %+v, want id %d dengan nama pasien", got, current)
+	}
+}
+
+func TestGetDoctorsWorkingAtUsesDoctorTimezone(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// 01:30 UTC adalah 08:30 di Asia/Jakarta: dokter Jakarta sudah praktik
+	// (08:00-16:00), dokter yang memakai zona waktu aplikasi (UTC) belum.
+	jakarta := createTestDoctor(t, pool)
+	utc := createTestDoctor(t, pool)
+	execSQL(t, pool, "UPDATE doctors SET timezone = 'Asia/Jakarta' WHERE id = $1", jakarta)
+
+	rec := serveJSON(t, GetDoctorsWorkingAtHandler(pool, cfg), http.MethodGet, "/doctors/working?at="+url.QueryEscape("2030-01-07T01:30:00Z"), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	doctors := decodeJSON[[]Doctor](t, rec)
+	working := func(id int) bool {
+		return slices.ContainsFunc(doctors, func(d Doctor) bool { return d.ID == id })
+	}
+	if !working(jakarta) {
+		t.Errorf("dokter %d (Asia/Jakarta) tidak ada di daftar, want praktik pukul 08:30 WIB", jakarta)
+	}
+	if working(utc) {
+		t.Errorf("dokter %d (UTC) ada di daftar, want belum praktik pukul 01:30 UTC", utc)
+	}
+}
+
+func TestGetDoctorCapacityForAWeek(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	day := func(offset, hour int) time.Time { return tomorrowAt(cfg, hour).AddDate(0, 0, offset) }
+
+	// Hari ke-1: dua janji temu; hari ke-2: dokter libur; hari ke-3: satu
+	// janji temu aktif dan satu yang dibatalkan (tidak dihitung).
+	insertTestAppointment(t, pool, patientID, doctorID, day(1, 9), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, doctorID, day(1, 10), StatusConfirmed)
+	execSQL(t, pool, "INSERT INTO doctor_time_off (doctor_id, off_date) VALUES ($1, $2)", doctorID, day(2, 0).Format(dateLayout))
+	insertTestAppointment(t, pool, patientID, doctorID, day(3, 9), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, doctorID, day(3, 11), StatusCancelled)
+
+	target := fmt.Sprintf("/doctors/%d/capacity?from=%s&to=%s", doctorID, day(0, 0).Format(dateLayout), day(6, 0).Format(dateLayout))
+	rec := serveJSON(t, routed("GET /doctors/{id}/capacity", GetDoctorCapacityHandler(pool, cfg)), http.MethodGet, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	// Praktik 08:00-16:00 dengan slot 30 menit = 16 slot per hari
+	want := []DayCapacity{
+		{Date: day(0, 0).Format(dateLayout), Total: 16, Booked: 0, Open: 16},
+		{Date: day(1, 0).Format(dateLayout), Total: 16, Booked: 2, Open: 14},
+		{Date: day(2, 0).Format(dateLayout), Total: 0, Booked: 0, Open: 0},
+		{Date: day(3, 0).Format(dateLayout), Total: 16, Booked: 1, Open: 15},
+		{Date: day(4, 0).Format(dateLayout), Total: 16, Booked: 0, Open: 16},
+		{Date: day(5, 0).Format(dateLayout), Total: 16, Booked: 0, Open: 16},
+		{Date: day(6, 0).Format(dateLayout), Total: 16, Booked: 0, Open: 16},
+	}
+	if got := decodeJSON[[]DayCapacity](t, rec); !slices.Equal(got, want) {
+		t.Fatalf("kapasitas = %+v, want %+v", got, want)
+	}
+}
+
+func TestMultiSpecialtyDoctorMatchesBothFilters(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	multi := createTestDoctor(t, pool)
+	onlyFirst := createTestDoctor(t, pool)
+	onlySecond := createTestDoctor(t, pool)
+	first := addTestSpecialty(t, pool, multi, onlyFirst)
+	second := addTestSpecialty(t, pool, multi, onlySecond)
+
+	handler := GetBookingOptionsHandler(pool, cfg)
+	date := tomorrowAt(cfg, 0).Format(dateLayout)
+	tests := []struct {
+		specialty string
+		want      []int
+	}{
+		{specialty: first, want: []int{multi, onlyFirst}},
+		{specialty: second, want: []int{multi, onlySecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.specialty, func(t *testing.T) {
+			target := fmt.Sprintf("/booking/options?specialty=%s&date=%s", url.QueryEscape(tt.specialty), date)
+			rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			options := decodeJSON[[]BookingOption](t, rec)
+			var ids []int
+			for _, o := range options {
+				ids = append(ids, o.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("dokter = %v, want %v", ids, tt.want)
+			}
+			// Spesialisasi utama tetap ada untuk client lama
+			d := options[0].Doctor
+			if d.Specialty != "Umum" || !slices.Contains(d.Specialties, first) || !slices.Contains(d.Specialties, second) {
+				t.Errorf("dokter %d: specialty = %q, specialties = %v, want utama Umum dan berisi %q dan %q", d.ID, d.Specialty, d.Specialties, first, second)
+			}
+		})
+	}
+}
+
+func TestGetDoctorCalendarTimeOffOverridesWorkingDay(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	day := func(offset int) string { return tomorrowAt(cfg, 0).AddDate(0, 0, offset).Format(dateLayout) }
+	execSQL(t, pool, "INSERT INTO doctor_time_off (doctor_id, off_date, reason) VALUES ($1, $2, 'Cuti')", doctorID, day(1))
+
+	target := fmt.Sprintf("/doctors/%d/calendar?from=%s&to=%s", doctorID, day(0), day(2))
+	rec := serveJSON(t, routed("GET /doctors/{id}/calendar", GetDoctorCalendarHandler(pool, cfg)), http.MethodGet, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got := decodeJSON[[]CalendarDay](t, rec)
+	if len(got) != 3 {
+		t.Fatalf("jumlah hari = %d, want 3: %+v", len(got), got)
+	}
+	for _, i := range []int{0, 2} {
+		want := CalendarDay{Date: day(i), Working: true, StartTime: "08:00:00", EndTime: "16:00:00"}
+		if got[i] != want {
+			t.Errorf("hari %d = %+v, want %+v", i, got[i], want)
+		}
+	}
+	// Jadwal mingguan tetap 08:00-16:00, tetapi hari itu dokter libur
+	off := got[1]
+	if off.Date != day(1) || off.Working || off.StartTime != "" || off.Off != "TIME_OFF" || off.Note == nil || *off.Note != "Cuti" {
+		t.Errorf("hari libur = %+v (note %v), want tidak praktik dengan off TIME_OFF dan note Cuti", off, off.Note)
+	}
+}
+
+func TestGetDoctorAppointmentsByDateBusyDay(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patient := func(name string) int {
+		id := createTestPatient(t, pool)
+		execSQL(t, pool, "UPDATE patients SET full_name = $1 WHERE id = $2", name, id)
+		return id
+	}
+	// Dimasukkan tidak berurutan; response harus urut menurut jam
+	third := insertTestAppointment(t, pool, patient("Citra"), doctorID, tomorrowAt(cfg, 11), StatusCancelled)
+	first := insertTestAppointment(t, pool, patient("Ani"), doctorID, tomorrowAt(cfg, 8), StatusCheckedIn)
+	second := insertTestAppointment(t, pool, patient("Budi"), doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	fourth := insertTestAppointment(t, pool, patient("Dewi"), doctorID, tomorrowAt(cfg, 14), StatusRescheduled)
+	insertTestAppointment(t, pool, patient("Eko"), doctorID, tomorrowAt(cfg, 15), StatusHeld)
+	insertTestAppointment(t, pool, patient("Fajar"), doctorID, tomorrowAt(cfg, 9).AddDate(0, 0, 1), StatusConfirmed)
+
+	handler := routed("GET /doctors/{id}/appointments/by-date", GetDoctorAppointmentsByDateHandler(pool, cfg))
+	target := fmt.Sprintf("/doctors/%d/appointments/by-date?date=%s", doctorID, tomorrowAt(cfg, 0).Format(dateLayout))
+	rec := serveJSON(t, handler, http.MethodGet, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got := decodeJSON[[]CurrentAppointmentResponse](t, rec)
+	want := []struct {
+		id     int
+		name   string
+		status string
+	}{
+		{first, "Ani", StatusCheckedIn},
+		{second, "Budi", StatusConfirmed},
+		{third, "Citra", StatusCancelled},
+		{fourth, "Dewi", StatusRescheduled},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("jumlah janji temu = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].ID != w.id || got[i].PatientName != w.name || got[i].Status != w.status {
+			t.Errorf("janji temu %d = {%d %s %s}, want {%d %s %s}", i, got[i].ID, got[i].PatientName, got[i].Status, w.id, w.name, w.status)
+		}
+	}
+
+	// Hari tanpa janji temu mengembalikan [] (bukan null)
+	target = fmt.Sprintf("/doctors/%d/appointments/by-date?date=%s", doctorID, tomorrowAt(cfg, 0).AddDate(0, 0, 2).Format(dateLayout))
+	rec = serveJSON(t, handler, http.MethodGet, target, nil)
+	if body := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || body != "[]" {
+		t.Fatalf("hari kosong: status = %d, body = %s, want 200 []", rec.Code, body)
+	}
+}
+
+func TestGetDoctorConflictsReportsOverlappingPairs(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	at := func(hour, minute int) time.Time {
+		return tomorrowAt(cfg, hour).Add(time.Duration(minute) * time.Minute)
+	}
+
+	// Data lama: 09:00-09:20 dan 09:20-09:40 bertumpuk (selisih < SlotDuration),
+	// 09:00-09:40 tidak. Janji temu yang dibatalkan dan yang berjauhan diabaikan.
+	a := insertTestAppointment(t, pool, patientID, doctorID, at(9, 0), StatusConfirmed)
+	b := insertTestAppointment(t, pool, patientID, doctorID, at(9, 20), StatusConfirmed)
+	c := insertTestAppointment(t, pool, patientID, doctorID, at(9, 40), StatusCheckedIn)
+	insertTestAppointment(t, pool, patientID, doctorID, at(9, 10), StatusCancelled)
+	insertTestAppointment(t, pool, patientID, doctorID, at(11, 0), StatusConfirmed)
+
+	handler := routed("GET /doctors/{id}/conflicts", GetDoctorConflictsHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodGet, fmt.Sprintf("/doctors/%d/conflicts", doctorID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got := decodeJSON[[]AppointmentConflict](t, rec)
+	want := [][2]int{{a, b}, {b, c}}
+	if len(got) != len(want) {
+		t.Fatalf("jumlah pasangan bentrok = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].First.ID != w[0] || got[i].Second.ID != w[1] {
+			t.Errorf("pasangan %d = {%d %d}, want {%d %d}", i, got[i].First.ID, got[i].Second.ID, w[0], w[1])
+		}
+	}
+
+	// Dokter tanpa bentrok mengembalikan [] (bukan null)
+	otherID := createTestDoctor(t, pool)
+	rec = serveJSON(t, handler, http.MethodGet, fmt.Sprintf("/doctors/%d/conflicts", otherID), nil)
+	if body := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || body != "[]" {
+		t.Fatalf("tanpa bentrok: status = %d, body = %s, want 200 []", rec.Code, body)
+	}
+
+	rec = serveJSON(t, handler, http.MethodGet, "/doctors/0/conflicts", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ID tidak valid: status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+}
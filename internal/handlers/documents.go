@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/storage"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PatientDocument adalah metadata dokumen (scan KTP, foto, dll) milik pasien.
+type PatientDocument struct {
+	ID          int       `json:"id"`
+	PatientID   int       `json:"patientId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	StorageKey  string    `json:"storageKey"`
+	CreatedAt   Timestamp `json:"createdAt"`
+}
+
+// UploadPatientDocumentHandler menerima unggahan dokumen pasien (multipart,
+// field "file"), menyimpan isinya ke store, lalu mencatat metadata-nya.
+// Ukuran dan content type dibatasi sesuai konfigurasi.
+func UploadPatientDocumentHandler(dbpool *pgxpool.Pool, store storage.Storage, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID pasien
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Baca file dari form multipart. Body dibatasi sedikit di atas ukuran
+		// maksimum untuk memberi ruang bagi header multipart.
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.DocumentMaxSize+1<<20)
+		if err := r.ParseMultipartForm(cfg.DocumentMaxSize); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, fmt.Sprintf("Ukuran file maksimal %d byte.", cfg.DocumentMaxSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Request harus berupa multipart/form-data", http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Field 'file' wajib diisi", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		if header.Size > cfg.DocumentMaxSize {
+			http.Error(w, fmt.Sprintf("Ukuran file maksimal %d byte.", cfg.DocumentMaxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// 3. Tentukan content type dari isi file, bukan dari header yang dikirim client
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			http.Error(w, "Gagal membaca file", http.StatusBadRequest)
+			return
+		}
+		head = head[:n]
+		contentType := http.DetectContentType(head)
+		contentType, _, _ = strings.Cut(contentType, ";")
+		if !slices.Contains(cfg.DocumentAllowedTypes, contentType) {
+			http.Error(w, fmt.Sprintf("Tipe file %s tidak diizinkan.", contentType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		// 4. Pastikan pasien ada sebelum menyimpan file
+		var exists bool
+		err = dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM patients WHERE id = $1)", patientID).Scan(&exists)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		// 5. Simpan isi file. Nama file dari client tidak dipakai sebagai path.
+		key, err := newStorageKey(patientID, header.Filename)
+		if err != nil {
+			http.Error(w, "Gagal menyimpan dokumen", http.StatusInternalServerError)
+			return
+		}
+		if err := store.Save(key, io.MultiReader(bytes.NewReader(head), file)); err != nil {
+			log.Printf("Gagal menyimpan file dokumen pasien: %v", err)
+			http.Error(w, "Gagal menyimpan dokumen", http.StatusInternalServerError)
+			return
+		}
+
+		// 6. Catat metadata dokumen
+		doc := PatientDocument{
+			PatientID:   patientID,
+			Filename:    filepath.Base(header.Filename),
+			ContentType: contentType,
+			SizeBytes:   header.Size,
+			StorageKey:  key,
+		}
+		query := `INSERT INTO patient_documents (patient_id, filename, content_type, size_bytes, storage_key)
+                  VALUES ($1, $2, $3, $4, $5)
+                  RETURNING id, created_at`
+
+		err = dbpool.QueryRow(r.Context(), query, doc.PatientID, doc.Filename, doc.ContentType, doc.SizeBytes, doc.StorageKey).Scan(&doc.ID, &doc.CreatedAt)
+		if err != nil {
+			log.Printf("Gagal menyimpan metadata dokumen pasien: %v", err)
+			if err := store.Delete(key); err != nil {
+				log.Printf("Gagal menghapus file dokumen yatim %s: %v", key, err)
+			}
+			http.Error(w, "Gagal menyimpan dokumen", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// GetPatientDocumentsHandler mengambil daftar metadata dokumen milik seorang pasien.
+func GetPatientDocumentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID := r.PathValue("id")
+		limit, offset, err := parsePagination(r, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		query := `SELECT id, patient_id, filename, content_type, size_bytes, storage_key, created_at
+                  FROM patient_documents
+                  WHERE patient_id = $1
+                  ORDER BY created_at DESC, id DESC
+                  LIMIT $2 OFFSET $3`
+
+		var total int
+		err = dbpool.QueryRow(r.Context(), "SELECT COUNT(*) FROM patient_documents WHERE patient_id = $1", patientID).Scan(&total)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokumen", http.StatusInternalServerError)
+			return
+		}
+
+		rows, err := dbpool.Query(r.Context(), query, patientID, limit, offset)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokumen", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		var docs []PatientDocument
+		for rows.Next() {
+			var d PatientDocument
+			if err := rows.Scan(&d.ID, &d.PatientID, &d.Filename, &d.ContentType, &d.SizeBytes, &d.StorageKey, &d.CreatedAt); err != nil {
+				http.Error(w, "Gagal memindai data dokumen", http.StatusInternalServerError)
+				return
+			}
+			docs = append(docs, d)
+		}
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data dokumen", http.StatusInternalServerError)
+			return
+		}
+
+		if docs == nil {
+			docs = []PatientDocument{}
+		}
+
+		setPaginationHeaders(w, r, total, limit, offset)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(docs)
+	}
+}
+
+// newStorageKey membuat key acak untuk file dokumen, mempertahankan ekstensi aslinya.
+func newStorageKey(patientID int, filename string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("patients/%d/%s%s", patientID, hex.EncodeToString(b), filepath.Ext(filepath.Base(filename))), nil
+}
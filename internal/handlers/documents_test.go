@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/storage"
+)
+
+// uploadRoute adalah pola route UploadPatientDocumentHandler di cmd/api/main.go.
+const uploadRoute = "POST /patients/{id}/documents"
+
+// pngHeader adalah signature file PNG, cukup untuk http.DetectContentType.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// newUploadRequest membuat request multipart dengan field "file" berisi content.
+func newUploadRequest(t *testing.T, patientID int, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("Gagal membuat form file: %v", err)
+	}
+	fw.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/patients/%d/documents", patientID), &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// testDocumentConfig mengembalikan konfigurasi dengan batas dokumen default.
+func testDocumentConfig() *config.Config {
+	cfg := testConfig()
+	cfg.DocumentMaxSize = 5 << 20
+	cfg.DocumentAllowedTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+	return cfg
+}
+
+func TestUploadPatientDocumentRejectsOversizedFile(t *testing.T) {
+	cfg := testDocumentConfig()
+	cfg.DocumentMaxSize = 1024
+	store, _ := storage.NewLocalStorage(t.TempDir())
+
+	// Ukuran divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	content := slices.Concat(pngHeader, make([]byte, 4096))
+	rec := serve(routed(uploadRoute, UploadPatientDocumentHandler(nil, store, cfg)), newUploadRequest(t, 1, "foto.png", content))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestUploadPatientDocumentRejectsDisallowedType(t *testing.T) {
+	store, _ := storage.NewLocalStorage(t.TempDir())
+	rec := serve(routed(uploadRoute, UploadPatientDocumentHandler(nil, store, testDocumentConfig())), newUploadRequest(t, 1, "catatan.png", []byte("bukan gambar")))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestUploadPatientDocument(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testDocumentConfig()
+	dir := t.TempDir()
+	store, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	patientID := createTestPatient(t, pool)
+	content := slices.Concat(pngHeader, []byte("isi foto"))
+
+	rec := serve(routed(uploadRoute, UploadPatientDocumentHandler(pool, store, cfg)), newUploadRequest(t, patientID, "../../foto ktp.png", content))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	doc := decodeJSON[PatientDocument](t, rec)
+	if doc.PatientID != patientID || doc.Filename != "foto ktp.png" || doc.ContentType != "image/png" || doc.SizeBytes != int64(len(content)) {
+		t.Fatalf("dokumen = %+v", doc)
+	}
+
+	// Isi file tersimpan utuh di bawah folder storage
+	saved, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(doc.StorageKey)))
+	if err != nil {
+		t.Fatalf("file dokumen tidak tersimpan: %v", err)
+	}
+	if !bytes.Equal(saved, content) {
+		t.Errorf("isi file = %q, want %q", saved, content)
+	}
+
+	// Dokumen muncul di daftar dokumen pasien
+	list := serve(routed("GET /patients/{id}/documents", GetPatientDocumentsHandler(pool, cfg)), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/patients/%d/documents", patientID), nil))
+	docs := decodeJSON[[]PatientDocument](t, list)
+	if len(docs) != 1 || docs[0].ID != doc.ID {
+		t.Fatalf("daftar dokumen = %+v, want [%d]", docs, doc.ID)
+	}
+}
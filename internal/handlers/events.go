@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// publishEvent menerbitkan event domain (mis. "doctor.specialty_changed").
+// Untuk saat ini event ditulis ke log sebagai satu baris JSON sehingga bisa
+// diambil oleh log shipper; nanti bisa diganti dengan message broker.
+func publishEvent(name string, payload map[string]any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Gagal menerbitkan event %s: %v", name, err)
+		return
+	}
+	log.Printf("event=%s payload=%s", name, data)
+}
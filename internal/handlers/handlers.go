@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -22,34 +26,64 @@ type Patient struct {
 	KTPNumber   string    `json:"ktpNumber"`
 	FullName    string    `json:"fullName"`
 	DateOfBirth string    `json:"dateOfBirth"`
-	CreatedAt   time.Time `json:"createdAt"`
+	CreatedAt   Timestamp `json:"createdAt"`
+	// Active bernilai false untuk pasien yang dinonaktifkan; pasien tersebut
+	// tidak bisa membuat janji temu baru.
+	Active bool `json:"active"`
 }
 
 // Doctor merepresentasikan struktur data untuk seorang dokter.
 type Doctor struct {
-	ID        int    `json:"id"`
+	ID int `json:"id"`
+	// NIK kosong ("") jika dokter terdaftar tanpa NIK (DOCTOR_NIK_REQUIRED=false).
 	NIK       string `json:"nik"`
 	Name      string `json:"name"`
 	Specialty string `json:"specialty"`
+	// Specialties adalah semua spesialisasi dokter, termasuk Specialty
+	// (spesialisasi utama). Saat membuat/mengubah dokter, Specialty boleh
+	// dikosongkan; spesialisasi pertama di Specialties lalu menjadi yang utama.
+	Specialties []string `json:"specialties"`
+	// Timezone adalah zona waktu praktik dokter (nama IANA). null berarti
+	// memakai zona waktu aplikasi.
+	Timezone *string `json:"timezone"`
+	// ReminderLeadTime adalah jarak pengiriman pengingat sebelum janji temu
+	// dalam format durasi Go (mis. "48h", "2h"). null berarti memakai
+	// cfg.ReminderLeadTime.
+	ReminderLeadTime *string `json:"reminderLeadTime"`
+	// BufferMinutes adalah jeda minimum antara akhir satu janji temu dokter
+	// dan awal janji temu berikutnya (0 = tanpa jeda).
+	BufferMinutes int `json:"bufferMinutes"`
 }
 
 // Appointment merepresentasikan struktur data untuk janji temu.
 type Appointment struct {
-	ID              int       `json:"id"`
-	PatientID       int       `json:"patientId"`
-	DoctorID        int       `json:"doctorId"`
-	AppointmentDate time.Time `json:"appointmentDate"`
-	Status          string    `json:"status"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ID              int        `json:"id"`
+	PatientID       int        `json:"patientId"`
+	DoctorID        int        `json:"doctorId"`
+	AppointmentDate Timestamp  `json:"appointmentDate"`
+	Status          string     `json:"status"`
+	CreatedAt       Timestamp  `json:"createdAt"`
+	CheckedInAt     *Timestamp `json:"checkedInAt"`
+	HoldExpiresAt   *Timestamp `json:"holdExpiresAt,omitempty"`
+	ReminderSentAt  *Timestamp `json:"reminderSentAt"`
+	Reason          *string    `json:"reason"`
+	// CreatedBy adalah ID petugas yang membuat janji temu (header X-Actor-ID).
+	CreatedBy *string `json:"createdBy"`
+	// ConfirmedAt adalah waktu pasien mengonfirmasi kehadiran
+	// (POST /appointments/{id}/attendance); null berarti belum dikonfirmasi.
+	ConfirmedAt *Timestamp `json:"confirmedAt"`
+	// Priority adalah urgensi kunjungan: ROUTINE (default), URGENT, atau EMERGENCY.
+	Priority string `json:"priority"`
 }
 
 // AppointmentResponse adalah struktur data yang akan dikirim sebagai JSON.
 type AppointmentResponse struct {
-	ID              int       `json:"id"`
-	DoctorID        int       `json:"doctorId"`
-	DoctorName      string    `json:"doctorName"`
-	AppointmentDate time.Time `json:"appointmentDate"`
-	Status          string    `json:"status"`
+	ID              int        `json:"id"`
+	DoctorID        int        `json:"doctorId"`
+	DoctorName      string     `json:"doctorName"`
+	AppointmentDate Timestamp  `json:"appointmentDate"`
+	Status          string     `json:"status"`
+	CheckedInAt     *Timestamp `json:"checkedInAt"`
 }
 
 // RescheduleRequest adalah struktur data untuk body JSON saat reschedule.
@@ -77,54 +111,51 @@ type TimeOffRequest struct {
 	Reason  string `json:"reason,omitempty"` // omitempty berarti field ini opsional
 }
 
+// TimeOffResponse adalah struktur untuk menampilkan hari libur dokter.
+type TimeOffResponse struct {
+	ID      int     `json:"id"`
+	OffDate string  `json:"offDate"` // Format: YYYY-MM-DD
+	Reason  *string `json:"reason"`
+}
+
 // CreatePatientHandler menangani pembuatan pasien baru.
-func CreatePatientHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Nomor KTP divalidasi oleh ktpValidator; jika nil, dipakai NumericKTPValidator.
+// Pasien yang identitasnya sudah terdaftar ditolak dengan 409 (lihat
+// checkPatientIdentity).
+func CreatePatientHandler(dbpool *pgxpool.Pool, cfg *config.Config, ktpValidator KTPValidator) http.HandlerFunc {
+	if ktpValidator == nil {
+		ktpValidator = NumericKTPValidator{}
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		var p Patient
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		if err := decodeTolerantJSON(r.Body, &p); err != nil {
 			log.Printf("Error decoding JSON body: %v", err)
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
 
-		// Validasi input
-		if len(p.KTPNumber) != 16 {
-			http.Error(w, "Nomor KTP harus 16 digit", http.StatusBadRequest)
-			return
-		}
-		if match, _ := regexp.MatchString("^[0-9]+$", p.KTPNumber); !match {
-			http.Error(w, "Nomor KTP harus berupa angka.", http.StatusBadRequest)
-			return
-		}
-		if len(p.FullName) < 3 {
-			http.Error(w, "Nama lengkap minimal 3 karakter", http.StatusBadRequest)
-			return
-		}
-
-		// Validasi DAN KONVERSI format tanggal (ini diperlukan)
-		layout := "02-01-2006" // Format DD-MM-YYYY
-		dob, err := time.Parse(layout, p.DateOfBirth)
+		// Validasi input sekaligus konversi tanggal lahir
+		dob, err := validatePatient(&p, ktpValidator)
 		if err != nil {
-			http.Error(w, "Format tanggal lahir harus DD-MM-YYYY", http.StatusBadRequest)
+			writeValidationError(w, err)
 			return
 		}
-		// --- VALIDASI BARU: Cek apakah tanggal lahir ada di masa depan ---
-		if dob.After(time.Now()) {
-			http.Error(w, "Tanggal lahir tidak boleh ada di masa depan.", http.StatusBadRequest)
+
+		if err := checkPatientIdentity(r.Context(), dbpool, p.KTPNumber, dob, 0, cfg); err != nil {
+			writeSlotError(w, err)
 			return
 		}
 
 		// Masukkan data ke database menggunakan tanggal yang sudah dikonversi
 		query := `INSERT INTO patients (ktp_number, full_name, date_of_birth) 
                   VALUES ($1, $2, $3) 
-                  RETURNING id, created_at`
+                  RETURNING id, created_at, active`
 
-		err = dbpool.QueryRow(context.Background(), query, p.KTPNumber, p.FullName, dob).Scan(&p.ID, &p.CreatedAt)
+		err = dbpool.QueryRow(r.Context(), query, p.KTPNumber, p.FullName, dob).Scan(&p.ID, &p.CreatedAt, &p.Active)
 		if err != nil {
 			// Cek apakah error ini adalah error 'unique violation' dari Postgres
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) && pgErr.Code == "23505" { // 23505 adalah kode untuk unique_violation
-				http.Error(w, "Pasien dengan nomor KTP tersebut sudah terdaftar.", http.StatusConflict) // Kirim 409 Conflict
+			if msg, ok := uniqueViolationMessage(err); ok { // 23505 adalah kode untuk unique_violation
+				http.Error(w, msg, http.StatusConflict) // Kirim 409 Conflict
 				return
 			}
 			log.Printf("Gagal memasukkan pasien ke DB: %v", err)
@@ -139,18 +170,88 @@ func CreatePatientHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 	}
 }
 
+// GetPatientByKTPHandler mencari satu pasien berdasarkan nomor KTP
+// (GET /patients/by-ktp?ktp=...). KTP selalu diperlakukan sebagai string agar
+// angka 0 di depan tetap utuh, dan formatnya divalidasi oleh ktpValidator.
+// ?dob= (DD-MM-YYYY) opsional mempersempit pencarian ke tanggal lahir tersebut.
+// Pada mode KTP + tanggal lahir (cfg.PatientUniqueKTPAndDOB) beberapa pasien
+// bisa memakai KTP yang sama; jika lebih dari satu yang cocok, dikembalikan
+// 409 alih-alih salah satunya secara acak.
+func GetPatientByKTPHandler(dbpool *pgxpool.Pool, ktpValidator KTPValidator) http.HandlerFunc {
+	if ktpValidator == nil {
+		ktpValidator = NumericKTPValidator{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ktp := strings.TrimSpace(r.URL.Query().Get("ktp"))
+		if err := ktpValidator.ValidateKTP(ktp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var dob *time.Time
+		if v := r.URL.Query().Get("dob"); v != "" {
+			d, err := time.Parse(dobLayout, v)
+			if err != nil {
+				http.Error(w, "Format dob harus DD-MM-YYYY", http.StatusBadRequest)
+				return
+			}
+			dob = &d
+		}
+
+		var patients []Patient
+		query := `SELECT ` + patientColumns + `
+                  FROM patients
+                  WHERE ktp_number = $1 AND ($2::date IS NULL OR date_of_birth = $2)
+                  ORDER BY id
+                  LIMIT 2`
+
+		err := withRetry(r.Context(), func() error {
+			patients = nil
+			rows, err := dbpool.Query(r.Context(), query, ktp, dob)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var p Patient
+				if err := scanPatient(rows, &p); err != nil {
+					return err
+				}
+				patients = append(patients, p)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+		switch len(patients) {
+		case 0:
+			http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+			return
+		case 2:
+			http.Error(w, "Lebih dari satu pasien memakai KTP tersebut. Sertakan ?dob=DD-MM-YYYY.", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(patients[0])
+	}
+}
+
 // GetPatientByIDHandler adalah fungsi untuk mengambil satu pasien berdasarkan ID.
 func GetPatientByIDHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
 
 		var p Patient
-		var dob time.Time // Variabel sementara untuk menampung tanggal dari DB
-		query := `SELECT id, ktp_number, full_name, date_of_birth, created_at 
+		query := `SELECT ` + patientColumns + `
                   FROM patients 
                   WHERE id = $1`
 
-		err := dbpool.QueryRow(context.Background(), query, id).Scan(&p.ID, &p.KTPNumber, &p.FullName, &dob, &p.CreatedAt)
+		// Tanggal lahir dikonversi ke format DD-MM-YYYY oleh scanPatient
+		err := withRetry(r.Context(), func() error {
+			return scanPatient(dbpool.QueryRow(r.Context(), query, id), &p)
+		})
 		if err != nil {
 			if err.Error() == "no rows in result set" {
 				http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
@@ -160,54 +261,164 @@ func GetPatientByIDHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		// Konversi tanggal dari DB ke format DD-MM-YYYY untuk response
-		layout := "02-01-2006"
-		p.DateOfBirth = dob.Format(layout)
-
 		// Kirim response JSON
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(p)
 	}
 }
 
+// patientColumns adalah daftar kolom standar untuk dipindai ke struct Patient
+// lewat scanPatient. Urutannya harus sama dengan urutan Scan di bawah.
+const patientColumns = "id, ktp_number, full_name, date_of_birth, created_at, active"
+
+// qualifiedPatientColumns mengembalikan patientColumns dengan prefix alias tabel.
+func qualifiedPatientColumns(alias string) string {
+	return qualifyColumns(alias, patientColumns)
+}
+
+// scanPatient memindai satu baris hasil SELECT patientColumns dan mengubah
+// tanggal lahir ke format DD-MM-YYYY. Kolom tambahan bisa dipindai lewat extra.
+func scanPatient(row pgx.Row, p *Patient, extra ...any) error {
+	var dob time.Time
+	dest := []any{&p.ID, &p.KTPNumber, &p.FullName, &dob, &p.CreatedAt, &p.Active}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	p.DateOfBirth = dob.Format(dobLayout)
+	return nil
+}
+
+// checkPatientIdentity menolak (409) data pasien yang identitasnya sudah
+// dipakai pasien lain: nomor KTP saja, atau KTP + tanggal lahir jika
+// cfg.PatientUniqueKTPAndDOB aktif. Unique constraint di database tetap
+// menjadi penjaga terakhir; pengecekan ini memastikan mode yang dipilih
+// berlaku walaupun constraint-nya belum disesuaikan. excludeID adalah ID
+// pasien yang sedang diubah (0 untuk pasien baru).
+func checkPatientIdentity(ctx context.Context, dbpool querier, ktp string, dob time.Time, excludeID int, cfg *config.Config) error {
+	var taken bool
+	err := dbpool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM patients
+                  WHERE ktp_number = $1 AND (NOT $2 OR date_of_birth = $3) AND id <> $4)`,
+		ktp, cfg.PatientUniqueKTPAndDOB, dob, excludeID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if !taken {
+		return nil
+	}
+	if cfg.PatientUniqueKTPAndDOB {
+		return &SlotError{http.StatusConflict, uniqueConstraintMessages["patients_ktp_number_dob_key"]}
+	}
+	return &SlotError{http.StatusConflict, uniqueConstraintMessages["patients_ktp_number_key"]}
+}
+
+// inactivePatientRescheduleMessage adalah pesan 409 saat janji temu milik
+// pasien nonaktif akan dijadwalkan ulang.
+const inactivePatientRescheduleMessage = "Pasien sudah dinonaktifkan, janji temunya tidak dapat dijadwalkan ulang."
+
+// checkPatientActive menolak booking baru untuk pasien yang dinonaktifkan.
+// Pasien yang tidak ada dibiarkan lolos; itu dijaga foreign key saat INSERT.
+func checkPatientActive(ctx context.Context, dbpool querier, patientID int) error {
+	var active bool
+	err := dbpool.QueryRow(ctx, "SELECT active FROM patients WHERE id = $1", patientID).Scan(&active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !active {
+		return &SlotError{http.StatusConflict, "Pasien sudah dinonaktifkan dan tidak dapat membuat janji temu baru."}
+	}
+	return nil
+}
+
+// checkAppointmentParties memastikan pasien dan dokter sebuah booking baru ada
+// (404 jika tidak) dan pasiennya masih aktif (409), dengan satu query. Dipanggil
+// sebelum validateAppointmentSlot agar ID yang salah tidak perlu melewati
+// pengecekan jadwal dulu dan baru gagal di foreign key saat INSERT.
+func checkAppointmentParties(ctx context.Context, dbpool querier, patientID, doctorID int) error {
+	var patientActive *bool
+	var doctorExists bool
+	err := dbpool.QueryRow(ctx, `SELECT (SELECT active FROM patients WHERE id = $1),
+                  EXISTS (SELECT 1 FROM doctors WHERE id = $2)`, patientID, doctorID).Scan(&patientActive, &doctorExists)
+	if err != nil {
+		return err
+	}
+	switch {
+	case patientActive == nil:
+		return &SlotError{http.StatusNotFound, "Pasien dengan ID tersebut tidak ditemukan."}
+	case !doctorExists:
+		return &SlotError{http.StatusNotFound, "Dokter dengan ID tersebut tidak ditemukan."}
+	case !*patientActive:
+		return &SlotError{http.StatusConflict, "Pasien sudah dinonaktifkan dan tidak dapat membuat janji temu baru."}
+	}
+	return nil
+}
+
+// SetPatientActiveHandler mengaktifkan atau menonaktifkan pasien
+// (PATCH /patients/{id}/activate dan /deactivate). Data dan riwayat janji temu
+// pasien tetap tersimpan; pasien nonaktif hanya tidak bisa membuat janji temu baru.
+func SetPatientActiveHandler(dbpool *pgxpool.Pool, active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || patientID <= 0 {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		var p Patient
+		query := `UPDATE patients SET active = $1 WHERE id = $2
+                  RETURNING ` + patientColumns
+		err = scanPatient(dbpool.QueryRow(r.Context(), query, active, patientID), &p)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			log.Printf("Gagal mengubah status aktif pasien %s: %v", maskIdentifier(patientID), err)
+			http.Error(w, "Gagal menyimpan data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
 // CreateDoctorHandler adalah fungsi untuk mendaftarkan dokter baru.
-func CreateDoctorHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+func CreateDoctorHandler(dbpool *pgxpool.Pool, cache *DoctorCache, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Dekode request JSON ke dalam struct Doctor
 		var d Doctor
-		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		if err := decodeTolerantJSON(r.Body, &d); err != nil {
 			log.Printf("Error decoding JSON body: %v", err)
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
 
 		// 2. Validasi input
-		if len(d.NIK) != 10 {
-			http.Error(w, "NIK dokter harus 10 digit", http.StatusBadRequest)
+		if err := validateDoctor(&d, cfg.DoctorNIKRequired); err != nil {
+			writeValidationError(w, err)
 			return
 		}
-		if match, _ := regexp.MatchString("^[0-9]+$", d.NIK); !match {
-			http.Error(w, "NIK harus berupa angka.", http.StatusBadRequest)
-		}
-		if len(d.Name) < 3 {
-			http.Error(w, "Nama dokter minimal 3 karakter", http.StatusBadRequest)
-			return
-		}
-		if strings.TrimSpace(d.Specialty) == "" {
-			http.Error(w, "Specialty tidak boleh kosong.", http.StatusBadRequest)
+
+		// 3. Masukkan data dokter dan spesialisasinya dalam satu transaksi
+		ctx := r.Context()
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
 			return
 		}
+		defer tx.Rollback(ctx)
 
-		// 3. Masukkan data ke database
-		query := `INSERT INTO doctors (nik, name, specialty) 
-                  VALUES ($1, $2, $3) 
+		query := `INSERT INTO doctors (nik, name, specialty, timezone, reminder_lead_time, buffer_minutes) 
+                  VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6) 
                   RETURNING id`
 
-		err := dbpool.QueryRow(context.Background(), query, d.NIK, d.Name, d.Specialty).Scan(&d.ID)
+		err = tx.QueryRow(ctx, query, d.NIK, d.Name, d.Specialty, d.Timezone, reminderLeadDuration(d.ReminderLeadTime), d.BufferMinutes).Scan(&d.ID)
 		if err != nil {
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-				http.Error(w, "Dokter dengan NIK tersebut sudah terdaftar.", http.StatusConflict) // Kirim 409
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict) // Kirim 409
 				return
 			}
 			// (Nanti kita bisa tambahkan pengecekan NIK duplikat di sini)
@@ -215,6 +426,19 @@ func CreateDoctorHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
 			return
 		}
+		if err := saveDoctorSpecialties(ctx, tx, d.ID, d.Specialties); err != nil {
+			log.Printf("Gagal menyimpan spesialisasi dokter: %v", err)
+			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit dokter baru: %v", err)
+			http.Error(w, "Gagal menyimpan data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// Daftar dokter berubah, kosongkan cache
+		cache.Invalidate()
 
 		// 4. Kirim response JSON yang sukses
 		w.Header().Set("Content-Type", "application/json")
@@ -224,103 +448,150 @@ func CreateDoctorHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 }
 
 // GetAllDoctorsHandler adalah fungsi untuk mengambil semua data dokter.
-func GetAllDoctorsHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Daftar lengkap disimpan di cache agar request berikutnya tidak perlu ke
+// database; pagination diterapkan setelahnya. Dengan ?include=schedules, jadwal
+// mingguan setiap dokter ikut disertakan (tanpa cache).
+func GetAllDoctorsHandler(dbpool *pgxpool.Pool, cache *DoctorCache, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// 1. Siapkan query untuk mengambil semua dokter
-		query := `SELECT id, nik, name, specialty FROM doctors`
-
-		rows, err := dbpool.Query(context.Background(), query)
+		limit, offset, err := parsePagination(r, cfg)
 		if err != nil {
-			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// ?include=schedules menyertakan jadwal mingguan setiap dokter
+		if r.URL.Query().Get("include") == "schedules" {
+			writeDoctorsWithSchedules(w, r, dbpool, limit, offset)
 			return
 		}
-		defer rows.Close()
+
+		doctors, cacheVersion, ok := cache.Get()
+		if ok {
+			setPaginationHeaders(w, r, len(doctors), limit, offset)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(paginate(doctors, limit, offset))
+			return
+		}
+
+		// 1. Siapkan query untuk mengambil semua dokter
+		query := `SELECT ` + doctorColumns + ` FROM doctors ORDER BY id`
 
 		// 2. Looping melalui hasil query dan masukkan ke dalam slice
-		var doctors []Doctor
-		for rows.Next() {
-			var d Doctor
-			if err := rows.Scan(&d.ID, &d.NIK, &d.Name, &d.Specialty); err != nil {
-				http.Error(w, "Gagal memindai data dokter", http.StatusInternalServerError)
-				return
+		err = withRetry(r.Context(), func() error {
+			doctors = nil
+			rows, err := dbpool.Query(r.Context(), query)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var d Doctor
+				if err := scanDoctor(rows, &d); err != nil {
+					return err
+				}
+				doctors = append(doctors, d)
 			}
-			doctors = append(doctors, d)
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
 		}
 
 		// Jika tidak ada dokter sama sekali, kembalikan array kosong, bukan error
 		if doctors == nil {
 			doctors = []Doctor{}
 		}
+		cache.Set(cacheVersion, doctors)
 
 		// 3. Kirim response JSON
+		setPaginationHeaders(w, r, len(doctors), limit, offset)
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(doctors)
+		json.NewEncoder(w).Encode(paginate(doctors, limit, offset))
 	}
 }
 
 // CreateAppointmentHandler menangani pembuatan janji temu baru.
-func CreateAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+func CreateAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Dekode request JSON
 		var appt Appointment
 		if err := json.NewDecoder(r.Body).Decode(&appt); err != nil {
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
-
-		// --- LOGIKA VALIDASI JADWAL (DITAMBAHKAN DI SINI) ---
-		apptDate := appt.AppointmentDate
-		doctorID := appt.DoctorID
-
-		// 2. Pengecekan #1: Apakah dokter libur pada tanggal tersebut?
-		var count int
-		err := dbpool.QueryRow(context.Background(), "SELECT COUNT(*) FROM doctor_time_off WHERE doctor_id = $1 AND off_date = $2", doctorID, apptDate.Format("2006-01-02")).Scan(&count)
-		if err != nil || count > 0 {
-			http.Error(w, "Dokter tidak tersedia pada tanggal tersebut (libur).", http.StatusConflict) // 409 Conflict
+		if err := validateAppointmentRequest(appt); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if err := validateReason(&appt.Reason); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if err := validatePriority(&appt.Priority); err != nil {
+			writeValidationError(w, err)
 			return
 		}
 
-		// 3. Pengecekan #2: Apakah sesuai dengan jadwal kerja mingguan?
-		dayOfWeek := int(apptDate.Weekday())
-		if dayOfWeek == 0 {
-			dayOfWeek = 7
-		} // Konversi Minggu dari 0 ke 7
-
-		var startTime, endTime string
-		err = dbpool.QueryRow(context.Background(), "SELECT start_time, end_time FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = $2", doctorID, dayOfWeek).Scan(&startTime, &endTime)
-
-		requestTime := apptDate.Format("15:04:05")
-		if err != nil || requestTime < startTime || requestTime > endTime {
-			http.Error(w, "Jadwal yang diminta di luar jam kerja dokter.", http.StatusConflict)
+		// 2. Pasien dan dokter harus ada, dan pasien nonaktif tidak boleh
+		// membuat janji temu baru
+		if err := checkAppointmentParties(r.Context(), dbpool, appt.PatientID, appt.DoctorID); err != nil {
+			writeSlotError(w, err)
 			return
 		}
 
-		// 4. Pengecekan #3: Apakah bentrok dengan janji temu lain?
-		err = dbpool.QueryRow(context.Background(), "SELECT COUNT(*) FROM appointments WHERE doctor_id = $1 AND appointment_date = $2", doctorID, apptDate).Scan(&count)
-		if err != nil || count > 0 {
-			http.Error(w, "Slot waktu yang diminta sudah terisi. Silakan pilih jam lain.", http.StatusConflict)
+		// Validasi jadwal: hari libur, jam kerja, dan bentrok dengan janji temu pasien lainnya
+		err := validateAppointmentSlot(r.Context(), dbpool, slotCheck{
+			DoctorID:  appt.DoctorID,
+			PatientID: appt.PatientID,
+			Date:      appt.AppointmentDate.Time,
+		}, cfg)
+		if err != nil {
+			writeSlotError(w, err)
 			return
 		}
-		// --- AKHIR VALIDASI JADWAL ---
 
-		// 5. Jika lolos, masukkan data ke database
-		query := `INSERT INTO appointments (patient_id, doctor_id, appointment_date) 
-                  VALUES ($1, $2, $3) 
-                  RETURNING id, status, created_at`
+		// Batas janji temu per pasien per hari (admin boleh melewati batas ini)
+		if !isAdmin(r, cfg) {
+			if err := checkPatientDailyLimit(r.Context(), dbpool, appt.PatientID, appt.DoctorID, appt.AppointmentDate.Time, cfg); err != nil {
+				writeSlotError(w, err)
+				return
+			}
+		}
+
+		// Hold yang sudah kedaluwarsa tidak boleh menghalangi slot
+		if err := expireStaleHolds(r.Context(), dbpool); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
 
-		err = dbpool.QueryRow(context.Background(), query, appt.PatientID, appt.DoctorID, appt.AppointmentDate).Scan(&appt.ID, &appt.Status, &appt.CreatedAt)
+		// 3. Jika lolos, masukkan data ke database.
+		// Bentrok slot tidak dicek terlebih dahulu: unique index
+		// appointments_doctor_slot_unique yang menjaganya, sehingga dua request
+		// bersamaan tidak bisa sama-sama lolos.
+		query := `INSERT INTO appointments (patient_id, doctor_id, appointment_date, reason, status, created_by, priority) 
+                  VALUES ($1, $2, $3, $4, $5, $6, $7) 
+                  RETURNING ` + appointmentColumns
+
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, appt.PatientID, appt.DoctorID, appt.AppointmentDate, appt.Reason, cfg.DefaultAppointmentStatus, actorID(r), appt.Priority), &appt)
 		if err != nil {
-			// (Penanganan foreign key error)
-			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" {
-				http.Error(w, "Patient atau Doctor dengan ID tersebut tidak ditemukan.", http.StatusNotFound)
-				return
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				switch pgErr.Code {
+				case "23505": // unique_violation: slot dokter sudah terisi
+					http.Error(w, constraintMessage(pgErr), http.StatusConflict)
+					return
+				case "23503": // foreign_key_violation
+					http.Error(w, "Patient atau Doctor dengan ID tersebut tidak ditemukan.", http.StatusNotFound)
+					return
+				}
 			}
 			log.Printf("Gagal menyimpan janji temu: %v", err)
 			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
 			return
 		}
 
-		// 6. Kirim response JSON yang sukses
+		// 4. Kirim response JSON yang sukses
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(appt)
@@ -328,35 +599,75 @@ func CreateAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 }
 
 // GetAppointmentsByPatientIDHandler mengambil semua janji temu milik satu pasien.
-func GetAppointmentsByPatientIDHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Filter opsional ?specialty= hanya menampilkan janji temu dengan dokter spesialisasi tersebut.
+func GetAppointmentsByPatientIDHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// 1. Ambil ID pasien dari URL
+		// 1. Ambil ID pasien dari URL dan parameter pagination
 		patientID := r.PathValue("id")
+		limit, offset, err := parsePagination(r, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Filter opsional ?specialty= harus salah satu spesialisasi dokter yang ada
+		specialty := strings.TrimSpace(r.URL.Query().Get("specialty"))
+		if specialty != "" {
+			var known bool
+			err := dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM doctor_specialties WHERE LOWER(specialty) = LOWER($1))", specialty).Scan(&known)
+			if err != nil {
+				http.Error(w, "Gagal memeriksa spesialisasi", http.StatusInternalServerError)
+				return
+			}
+			if !known {
+				http.Error(w, "specialty tidak dikenal", http.StatusBadRequest)
+				return
+			}
+		}
 
 		// 2. Query ke database dengan JOIN untuk mendapatkan nama dokter
 		query := `
-            SELECT a.id, a.doctor_id, d.name, a.appointment_date, a.status
+            SELECT a.id, a.doctor_id, d.name, a.appointment_date, a.status, a.checked_in_at
             FROM appointments a
             JOIN doctors d ON a.doctor_id = d.id
             WHERE a.patient_id = $1
-            ORDER BY a.appointment_date DESC`
-
-		rows, err := dbpool.Query(context.Background(), query, patientID)
-		if err != nil {
-			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
+            AND ($4 = '' OR ` + doctorHasSpecialty("d", "$4") + `)
+            ORDER BY a.appointment_date DESC, a.id DESC
+            LIMIT $2 OFFSET $3`
 
 		// 3. Looping melalui hasil dan masukkan ke dalam slice
+		countQuery := `
+            SELECT COUNT(*)
+            FROM appointments a
+            JOIN doctors d ON a.doctor_id = d.id
+            WHERE a.patient_id = $1
+            AND ($2 = '' OR ` + doctorHasSpecialty("d", "$2") + `)`
+
 		var appointments []AppointmentResponse
-		for rows.Next() {
-			var appt AppointmentResponse
-			if err := rows.Scan(&appt.ID, &appt.DoctorID, &appt.DoctorName, &appt.AppointmentDate, &appt.Status); err != nil {
-				http.Error(w, "Gagal memindai data janji temu", http.StatusInternalServerError)
-				return
+		var total int
+		err = withRetry(r.Context(), func() error {
+			appointments = nil
+			if err := dbpool.QueryRow(r.Context(), countQuery, patientID, specialty).Scan(&total); err != nil {
+				return err
+			}
+			rows, err := dbpool.Query(r.Context(), query, patientID, limit, offset, specialty)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var appt AppointmentResponse
+				if err := rows.Scan(&appt.ID, &appt.DoctorID, &appt.DoctorName, &appt.AppointmentDate, &appt.Status, &appt.CheckedInAt); err != nil {
+					return err
+				}
+				appointments = append(appointments, appt)
 			}
-			appointments = append(appointments, appt)
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
 		}
 
 		if appointments == nil {
@@ -364,13 +675,17 @@ func GetAppointmentsByPatientIDHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		}
 
 		// 4. Kirim response JSON
+		setPaginationHeaders(w, r, total, limit, offset)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(appointments)
 	}
 }
 
 // RescheduleAppointmentHandler menangani penjadwalan ulang janji temu.
-func RescheduleAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Janji temu yang akan dimulai kurang dari cfg.MinRescheduleNotice lagi
+// tidak bisa dijadwalkan ulang, kecuali oleh admin. Hanya janji temu dengan
+// status di cfg.ReschedulableStatuses yang boleh dijadwalkan ulang.
+func RescheduleAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Ambil ID janji temu dari URL
 		appointmentID := r.PathValue("id")
@@ -378,13 +693,19 @@ func RescheduleAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		// 2. Dekode body JSON
 		var req RescheduleRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
 
-		// 3. Ambil DoctorID dari janji temu yang ada
-		var doctorID int
-		err := dbpool.QueryRow(context.Background(), "SELECT doctor_id FROM appointments WHERE id = $1", appointmentID).Scan(&doctorID)
+		// 3. Ambil dokter, pasien (beserta status aktifnya), dan jadwal saat ini dari janji temu yang ada
+		var id, doctorID, patientID int
+		var currentDate time.Time
+		var status string
+		var patientActive bool
+		err := dbpool.QueryRow(r.Context(), `SELECT a.id, a.doctor_id, a.patient_id, a.appointment_date, a.status, p.active
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  WHERE a.id = $1`, appointmentID).Scan(&id, &doctorID, &patientID, &currentDate, &status, &patientActive)
 		if err != nil {
 			if err.Error() == "no rows in result set" {
 				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
@@ -394,62 +715,177 @@ func RescheduleAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		// --- LOGIKA VALIDASI JADWAL ---
-		newDate := req.NewAppointmentDate
-
-		// 4. Pengecekan #1: Apakah dokter libur pada tanggal tersebut?
-		var count int
-		err = dbpool.QueryRow(context.Background(), "SELECT COUNT(*) FROM doctor_time_off WHERE doctor_id = $1 AND off_date = $2", doctorID, newDate.Format("2006-01-02")).Scan(&count)
-		if err != nil || count > 0 {
-			http.Error(w, "Dokter tidak tersedia pada tanggal tersebut (libur).", http.StatusConflict) // 409 Conflict
+		// Tolak jika status janji temu tidak boleh dijadwalkan ulang
+		if !slices.Contains(cfg.ReschedulableStatuses, status) {
+			http.Error(w, fmt.Sprintf("Janji temu berstatus %s tidak dapat dijadwalkan ulang.", status), http.StatusConflict)
 			return
 		}
 
-		// 5. Pengecekan #2: Apakah sesuai dengan jadwal kerja mingguan?
-		dayOfWeek := int(newDate.Weekday())
-		if dayOfWeek == 0 {
-			dayOfWeek = 7
-		} // Konversi Minggu dari 0 ke 7
+		// Tolak jika pasiennya sudah dinonaktifkan
+		if !patientActive {
+			http.Error(w, inactivePatientRescheduleMessage, http.StatusConflict)
+			return
+		}
 
-		var startTime, endTime time.Time
-		err = dbpool.QueryRow(context.Background(), "SELECT start_time, end_time FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = $2", doctorID, dayOfWeek).Scan(&startTime, &endTime)
+		// Tolak jika jadwal baru sama persis dengan jadwal saat ini
+		if req.NewAppointmentDate.Equal(currentDate) {
+			http.Error(w, "Jadwal baru sama dengan jadwal saat ini.", http.StatusUnprocessableEntity)
+			return
+		}
 
-		requestTime := newDate.Format("15:04:05") // Format HH:MM:SS
-		if err != nil || requestTime < startTime.Format("15:04:05") || requestTime > endTime.Format("15:04:05") {
-			http.Error(w, "Jadwal yang diminta di luar jam kerja dokter.", http.StatusConflict)
+		// Tolak jika janji temu sudah terlalu dekat (admin boleh melewati aturan ini)
+		if cfg.MinRescheduleNotice > 0 && time.Until(currentDate) < cfg.MinRescheduleNotice && !isAdmin(r, cfg) {
+			http.Error(w, fmt.Sprintf("Janji temu tidak dapat dijadwalkan ulang kurang dari %s sebelum dimulai.", cfg.MinRescheduleNotice), http.StatusConflict)
 			return
 		}
 
-		// 6. Pengecekan #3: Apakah bentrok dengan janji temu lain?
-		err = dbpool.QueryRow(context.Background(), "SELECT COUNT(*) FROM appointments WHERE doctor_id = $1 AND appointment_date = $2 AND id != $3", doctorID, newDate, appointmentID).Scan(&count)
-		if err != nil || count > 0 {
-			http.Error(w, "Slot waktu yang diminta sudah terisi. Silakan pilih jam lain.", http.StatusConflict)
+		// 4. Validasi jadwal baru. Janji temu ini sendiri dikecualikan dari
+		// pengecekan bentrok agar tidak dianggap bentrok dengan dirinya sendiri.
+		newDate := req.NewAppointmentDate
+		err = validateAppointmentSlot(r.Context(), dbpool, slotCheck{
+			DoctorID:  doctorID,
+			PatientID: patientID,
+			Date:      newDate,
+			ExcludeID: id,
+		}, cfg)
+		if err != nil {
+			writeSlotError(w, err)
 			return
 		}
 
-		// --- AKHIR VALIDASI JADWAL ---
+		if err := expireStaleHolds(r.Context(), dbpool); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
 
-		// 7. Jika semua validasi lolos, update janji temu
+		// 5. Jika semua validasi lolos, update janji temu
 		query := `UPDATE appointments SET appointment_date = $1, status = 'RESCHEDULED' 
-                  WHERE id = $2 
-                  RETURNING id, patient_id, doctor_id, appointment_date, status, created_at`
+                  WHERE id = $2 AND status = ANY($3)
+                  RETURNING ` + appointmentColumns
+
+		var updatedAppt Appointment
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, newDate, appointmentID, cfg.ReschedulableStatuses), &updatedAppt)
+		if err != nil {
+			// Status berubah di antara pengecekan dan update
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Status janji temu sudah berubah dan tidak dapat dijadwalkan ulang.", http.StatusConflict)
+				return
+			}
+			// Slot dokter dijaga oleh unique index appointments_doctor_slot_unique.
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal update janji temu: %v", err)
+			http.Error(w, "Gagal memperbarui janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 6. Kirim response sukses
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updatedAppt)
+	}
+}
+
+// RescheduleToNextSlotHandler memindahkan janji temu ke slot kosong berikutnya
+// milik dokter yang sama (POST /appointments/{id}/reschedule-next), tanpa
+// client perlu memilih jam. Pencarian dimulai setelah jadwal saat ini (atau
+// sekarang, jika jadwalnya sudah lewat) sejauh cfg.RescheduleSearchDays hari.
+// Aturan status dan batas waktu sama dengan RescheduleAppointmentHandler.
+func RescheduleToNextSlotHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Ambil janji temu yang akan dipindahkan
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+		var doctorID, patientID int
+		var currentDate time.Time
+		var status string
+		var patientActive bool
+		err = dbpool.QueryRow(ctx, `SELECT a.doctor_id, a.patient_id, a.appointment_date, a.status, p.active
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  WHERE a.id = $1`, appointmentID).Scan(&doctorID, &patientID, &currentDate, &status, &patientActive)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		if !slices.Contains(cfg.ReschedulableStatuses, status) {
+			http.Error(w, fmt.Sprintf("Janji temu berstatus %s tidak dapat dijadwalkan ulang.", status), http.StatusConflict)
+			return
+		}
+		if !patientActive {
+			http.Error(w, inactivePatientRescheduleMessage, http.StatusConflict)
+			return
+		}
+		if cfg.MinRescheduleNotice > 0 && time.Until(currentDate) < cfg.MinRescheduleNotice && !isAdmin(r, cfg) {
+			http.Error(w, fmt.Sprintf("Janji temu tidak dapat dijadwalkan ulang kurang dari %s sebelum dimulai.", cfg.MinRescheduleNotice), http.StatusConflict)
+			return
+		}
+
+		if err := expireStaleHolds(ctx, dbpool); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
+
+		// 2. Cari slot kosong berikutnya setelah jadwal saat ini
+		after := currentDate
+		if now := time.Now(); now.After(after) {
+			after = now
+		}
+		newDate, found, err := findNextOpenSlot(ctx, dbpool, slotCheck{
+			DoctorID:  doctorID,
+			PatientID: patientID,
+			ExcludeID: appointmentID,
+		}, after, cfg.RescheduleSearchDays, cfg)
+		if err != nil {
+			log.Printf("Gagal mencari slot kosong berikutnya: %v", err)
+			http.Error(w, "Gagal mencari slot kosong", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("Tidak ada slot kosong dalam %d hari ke depan.", cfg.RescheduleSearchDays), http.StatusConflict)
+			return
+		}
+
+		// 3. Pindahkan janji temu ke slot tersebut
+		query := `UPDATE appointments SET appointment_date = $1, status = 'RESCHEDULED'
+                  WHERE id = $2 AND status = ANY($3)
+                  RETURNING ` + appointmentColumns
 
 		var updatedAppt Appointment
-		err = dbpool.QueryRow(context.Background(), query, newDate, appointmentID).Scan(&updatedAppt.ID, &updatedAppt.PatientID, &updatedAppt.DoctorID, &updatedAppt.AppointmentDate, &updatedAppt.Status, &updatedAppt.CreatedAt)
+		err = scanAppointment(dbpool.QueryRow(ctx, query, newDate, appointmentID, cfg.ReschedulableStatuses), &updatedAppt)
 		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Status janji temu sudah berubah dan tidak dapat dijadwalkan ulang.", http.StatusConflict)
+				return
+			}
+			// Slot baru saja diambil request lain
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
 			log.Printf("Gagal update janji temu: %v", err)
 			http.Error(w, "Gagal memperbarui janji temu", http.StatusInternalServerError)
 			return
 		}
 
-		// 8. Kirim response sukses
+		// 4. Kirim response sukses
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(updatedAppt)
 	}
 }
 
 // AddDoctorScheduleHandler menambahkan jadwal kerja mingguan untuk dokter.
-func AddDoctorScheduleHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Jika cfg.AllowOvernightSchedules aktif, endTime sebelum startTime berarti
+// jadwal berakhir keesokan harinya (shift malam).
+func AddDoctorScheduleHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Ambil & Validasi ID Dokter dari URL
 		doctorIDStr := r.PathValue("id")
@@ -462,33 +898,33 @@ func AddDoctorScheduleHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		// 2. Dekode Request Body JSON
 		var req ScheduleRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
 
 		// 3. Validasi Data dari Body
 		// Validasi #1: Cek rentang hari
 		if req.DayOfWeek < 1 || req.DayOfWeek > 7 {
-			http.Error(w, "dayOfWeek harus antara 1 (Senin) dan 7 (Minggu).", http.StatusBadRequest)
+			http.Error(w, "dayOfWeek harus antara 1 (Senin) dan 7 (Minggu).", http.StatusUnprocessableEntity)
 			return
 		}
 
-		// Validasi #2 & #3: Cek format waktu
-		timeLayout := "15:04:05" // Format HH:MM:SS
-		startTime, err := time.Parse(timeLayout, req.StartTime)
-		if err != nil {
-			http.Error(w, "Format startTime tidak valid atau kosong, harus 'HH:MM:SS'", http.StatusBadRequest)
+		// Validasi #2 - #4: Cek format dan urutan waktu
+		if _, _, err := parseScheduleTimes(req, cfg.AllowOvernightSchedules); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 			return
 		}
-		endTime, err := time.Parse(timeLayout, req.EndTime)
+
+		// Validasi #5: Satu dokter hanya punya satu jadwal per hari. Dicek di sini
+		// agar pesannya jelas; unique constraint tetap menjaga request yang balapan.
+		var exists bool
+		err = dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = $2)", doctorID, req.DayOfWeek).Scan(&exists)
 		if err != nil {
-			http.Error(w, "Format endTime tidak valid atau kosong, harus 'HH:MM:SS'", http.StatusBadRequest)
+			http.Error(w, "Gagal memeriksa jadwal dokter", http.StatusInternalServerError)
 			return
 		}
-
-		// Validasi #4: Cek urutan waktu
-		if startTime.After(endTime) || startTime.Equal(endTime) {
-			http.Error(w, "startTime harus sebelum endTime.", http.StatusBadRequest)
+		if exists {
+			http.Error(w, uniqueConstraintMessages["doctor_schedules_doctor_id_day_of_week_key"], http.StatusConflict)
 			return
 		}
 
@@ -496,10 +932,16 @@ func AddDoctorScheduleHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		query := `INSERT INTO doctor_schedules (doctor_id, day_of_week, start_time, end_time)
                   VALUES ($1, $2, $3, $4)`
 
-		_, err = dbpool.Exec(context.Background(), query, doctorID, req.DayOfWeek, req.StartTime, req.EndTime)
+		_, err = dbpool.Exec(r.Context(), query, doctorID, req.DayOfWeek, req.StartTime, req.EndTime)
 		if err != nil {
-			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-				http.Error(w, "Jadwal untuk hari ini sudah ada.", http.StatusConflict)
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			// foreign_key_violation: doctor_id tidak ada di tabel doctors
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				http.Error(w, "Dokter tidak ditemukan.", http.StatusNotFound)
 				return
 			}
 			log.Printf("Gagal menyimpan jadwal dokter: %v", err)
@@ -522,26 +964,32 @@ func GetDoctorSchedulesHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		// 2. Query untuk mengambil semua jadwal dokter tersebut
 		query := `SELECT day_of_week, start_time, end_time FROM doctor_schedules WHERE doctor_id = $1`
 
-		rows, err := dbpool.Query(context.Background(), query, doctorID)
-		if err != nil {
-			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
 		// 3. Looping melalui hasil dan masukkan ke dalam slice
 		var schedules []ScheduleResponse
-		for rows.Next() {
-			var s ScheduleResponse
-			var startTime, endTime time.Time // Tampung sebagai time.Time dulu
-			if err := rows.Scan(&s.DayOfWeek, &startTime, &endTime); err != nil {
-				http.Error(w, "Gagal memindai data jadwal", http.StatusInternalServerError)
-				return
+		err := withRetry(r.Context(), func() error {
+			schedules = nil
+			rows, err := dbpool.Query(r.Context(), query, doctorID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var s ScheduleResponse
+				var startTime, endTime time.Time // Tampung sebagai time.Time dulu
+				if err := rows.Scan(&s.DayOfWeek, &startTime, &endTime); err != nil {
+					return err
+				}
+				// Format ke string HH:MM:SS
+				s.StartTime = startTime.Format("15:04:05")
+				s.EndTime = endTime.Format("15:04:05")
+				schedules = append(schedules, s)
 			}
-			// Format ke string HH:MM:SS
-			s.StartTime = startTime.Format("15:04:05")
-			s.EndTime = endTime.Format("15:04:05")
-			schedules = append(schedules, s)
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
+			return
 		}
 
 		if schedules == nil {
@@ -555,13 +1003,23 @@ func GetDoctorSchedulesHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 }
 
 // AddDoctorTimeOffHandler menambahkan tanggal libur untuk dokter.
-func AddDoctorTimeOffHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+// Tanggal yang sudah terdaftar ditolak dengan 409, kecuali dengan
+// ?idempotent=true yang mengembalikan 200 tanpa mengubah apa pun.
+// Dengan ?cancelConflicts=true, janji temu aktif dokter pada tanggal tersebut
+// (menurut zona waktu praktik dokter) ikut dibatalkan dalam transaksi yang
+// sama, dan ID-nya dikembalikan di cancelledAppointmentIds.
+func AddDoctorTimeOffHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		doctorID := r.PathValue("id")
+		ctx := r.Context()
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
 
 		var req TimeOffRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Request body tidak valid", http.StatusBadRequest)
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
 			return
 		}
 
@@ -569,17 +1027,44 @@ func AddDoctorTimeOffHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 		layout := "2006-01-02" // Format YYYY-MM-DD
 		offDate, err := time.Parse(layout, req.OffDate)
 		if err != nil {
-			http.Error(w, "Format tanggal harus YYYY-MM-DD", http.StatusBadRequest)
+			http.Error(w, "Format tanggal harus YYYY-MM-DD", http.StatusUnprocessableEntity)
 			return
 		}
 
+		// Validasi alasan: spasi dibuang, panjang maksimal maxReasonLength
+		req.Reason = strings.TrimSpace(req.Reason)
+		if utf8.RuneCountInString(req.Reason) > maxReasonLength {
+			var verr ValidationErrors
+			verr.add("reason", fmt.Sprintf("Alasan libur maksimal %d karakter.", maxReasonLength))
+			writeValidationError(w, &verr)
+			return
+		}
+		var reason *string
+		if req.Reason != "" {
+			reason = &req.Reason
+		}
+
+		// Dengan ?idempotent=true, tanggal yang sudah terdaftar dianggap sukses (200)
+		idempotent := r.URL.Query().Get("idempotent") == "true"
+		cancelConflicts := r.URL.Query().Get("cancelConflicts") == "true"
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
 		// Masukkan data ke database
 		query := `INSERT INTO doctor_time_off (doctor_id, off_date, reason) VALUES ($1, $2, $3)`
+		if idempotent {
+			query += ` ON CONFLICT (doctor_id, off_date) DO NOTHING`
+		}
 
-		_, err = dbpool.Exec(context.Background(), query, doctorID, offDate, req.Reason)
+		tag, err := tx.Exec(ctx, query, doctorID, offDate, reason)
 		if err != nil {
-			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-				http.Error(w, "Tanggal libur ini sudah terdaftar.", http.StatusConflict)
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
 				return
 			}
 			log.Printf("Gagal menyimpan tanggal libur: %v", err)
@@ -587,7 +1072,220 @@ func AddDoctorTimeOffHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		w.WriteHeader(http.StatusCreated)
-		w.Write([]byte(`{"message": "Tanggal libur berhasil ditambahkan"}`))
+		// Batalkan janji temu yang sudah terlanjur dibooking pada tanggal libur
+		cancelled := []int{}
+		if cancelConflicts {
+			loc, err := doctorLocation(ctx, tx, doctorID, cfg)
+			if err != nil {
+				http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+				return
+			}
+			dayStart := time.Date(offDate.Year(), offDate.Month(), offDate.Day(), 0, 0, 0, 0, loc)
+			rows, err := tx.Query(ctx, `UPDATE appointments SET status = $1
+                      WHERE doctor_id = $2 AND appointment_date >= $3 AND appointment_date < $4
+                      AND `+activeAppointmentCondition+`
+                      RETURNING id`, StatusCancelled, doctorID, dayStart, dayStart.AddDate(0, 0, 1))
+			if err != nil {
+				log.Printf("Gagal membatalkan janji temu pada tanggal libur: %v", err)
+				http.Error(w, "Gagal menyimpan tanggal libur", http.StatusInternalServerError)
+				return
+			}
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					rows.Close()
+					http.Error(w, "Gagal menyimpan tanggal libur", http.StatusInternalServerError)
+					return
+				}
+				cancelled = append(cancelled, id)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				log.Printf("Gagal membatalkan janji temu pada tanggal libur: %v", err)
+				http.Error(w, "Gagal menyimpan tanggal libur", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit tanggal libur: %v", err)
+			http.Error(w, "Gagal menyimpan tanggal libur", http.StatusInternalServerError)
+			return
+		}
+
+		status, message := http.StatusCreated, "Tanggal libur berhasil ditambahkan"
+		if idempotent && tag.RowsAffected() == 0 {
+			status, message = http.StatusOK, "Tanggal libur sudah terdaftar"
+		}
+		if !cancelConflicts {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"message": "` + message + `"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"message":                 message,
+			"cancelledAppointmentIds": cancelled,
+		})
+	}
+}
+
+// PatchPatientRequest adalah body JSON untuk PATCH /patients/{id}.
+// Field yang tidak dikirim (nil) tidak diubah.
+type PatchPatientRequest struct {
+	KTPNumber   *string `json:"ktpNumber"`
+	FullName    *string `json:"fullName"`
+	DateOfBirth *string `json:"dateOfBirth"`
+}
+
+// PatchPatientHandler mengubah sebagian data pasien: hanya field yang dikirim
+// yang diganti, lalu data hasil gabungan divalidasi ulang dengan aturan yang
+// sama seperti saat pendaftaran, termasuk keunikan identitas pasien (lihat
+// checkPatientIdentity). Body tanpa field apa pun ditolak dengan 400.
+func PatchPatientHandler(dbpool *pgxpool.Pool, cfg *config.Config, ktpValidator KTPValidator) http.HandlerFunc {
+	if ktpValidator == nil {
+		ktpValidator = NumericKTPValidator{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dan body
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+		var req PatchPatientRequest
+		if err := decodeTolerantJSON(r.Body, &req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if req.KTPNumber == nil && req.FullName == nil && req.DateOfBirth == nil {
+			http.Error(w, "Minimal satu field (ktpNumber, fullName, dateOfBirth) harus dikirim", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		// 2. Ambil data pasien saat ini
+		var p Patient
+		err = scanPatient(tx.QueryRow(ctx, "SELECT "+patientColumns+" FROM patients WHERE id = $1 FOR UPDATE", patientID), &p)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Terapkan field yang dikirim lalu validasi ulang
+		if req.KTPNumber != nil {
+			p.KTPNumber = *req.KTPNumber
+		}
+		if req.FullName != nil {
+			p.FullName = *req.FullName
+		}
+		if req.DateOfBirth != nil {
+			p.DateOfBirth = *req.DateOfBirth
+		}
+		dob, err := validatePatient(&p, ktpValidator)
+		if err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		if err := checkPatientIdentity(ctx, tx, p.KTPNumber, dob, patientID, cfg); err != nil {
+			writeSlotError(w, err)
+			return
+		}
+
+		// 4. Simpan perubahan
+		_, err = tx.Exec(ctx, "UPDATE patients SET ktp_number = $1, full_name = $2, date_of_birth = $3 WHERE id = $4", p.KTPNumber, p.FullName, dob, patientID)
+		if err != nil {
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal memperbarui pasien: %v", err)
+			http.Error(w, "Gagal menyimpan data pasien", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit perubahan pasien: %v", err)
+			http.Error(w, "Gagal menyimpan data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 5. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+// GetDoctorTimeOffHandler mengembalikan daftar hari libur seorang dokter beserta
+// alasannya, diurutkan dari tanggal terdekat. Filter opsional ?from= dan ?to=
+// (YYYY-MM-DD, inklusif).
+func GetDoctorTimeOffHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var from, to *string
+		if dr.From != nil {
+			s := dr.From.Format(dateLayout)
+			from = &s
+		}
+		if dr.To != nil {
+			s := dr.To.Format(dateLayout)
+			to = &s
+		}
+
+		query := `SELECT id, off_date, reason FROM doctor_time_off
+                  WHERE doctor_id = $1
+                  AND ($2::date IS NULL OR off_date >= $2::date)
+                  AND ($3::date IS NULL OR off_date <= $3::date)
+                  ORDER BY off_date`
+
+		timeOff := []TimeOffResponse{}
+		err = withRetry(r.Context(), func() error {
+			timeOff = timeOff[:0]
+			rows, err := dbpool.Query(r.Context(), query, doctorID, from, to)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var t TimeOffResponse
+				var offDate time.Time
+				if err := rows.Scan(&t.ID, &offDate, &t.Reason); err != nil {
+					return err
+				}
+				t.OffDate = offDate.Format(dateLayout)
+				timeOff = append(timeOff, t)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data hari libur dokter", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(timeOff)
 	}
 }
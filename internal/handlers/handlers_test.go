@@ -0,0 +1,609 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateAppointmentDuplicateSlotReturns409(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	date := tomorrowAt(cfg, 9)
+	handler := CreateAppointmentHandler(pool, cfg)
+
+	first := serveJSON(t, handler, http.MethodPost, "/appointments", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": date,
+	})
+	if first.Code != http.StatusCreated {
+		t.Fatalf("janji temu pertama: status = %d, want 201 (%s)", first.Code, first.Body)
+	}
+
+	second := serveJSON(t, handler, http.MethodPost, "/appointments", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": date,
+	})
+	if second.Code != http.StatusConflict {
+		t.Fatalf("janji temu kedua pada slot yang sama: status = %d, want 409 (%s)", second.Code, second.Body)
+	}
+}
+
+func TestWalkInUnknownDoctorReturns404(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	rec := serveJSON(t, WalkInHandler(pool, cfg, nil), http.MethodPost, "/walk-ins", map[string]any{
+		"patient":         Patient{KTPNumber: randomKTP(), FullName: "Pasien Walk-in", DateOfBirth: "01-01-1990"},
+		"doctorId":        math.MaxInt32,
+		"appointmentDate": tomorrowAt(cfg, 9),
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestWalkInReusesPatientAndRejectsTakenSlot(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	ktp := randomKTP()
+	t.Cleanup(func() { pool.Exec(context.Background(), "DELETE FROM patients WHERE ktp_number = $1", ktp) })
+	handler := WalkInHandler(pool, cfg, nil)
+
+	walkIn := func(hour int) *httptest.ResponseRecorder {
+		return serveJSON(t, handler, http.MethodPost, "/walk-ins", map[string]any{
+			"patient":         Patient{KTPNumber: ktp, FullName: "Pasien Walk-in", DateOfBirth: "01-01-1990"},
+			"doctorId":        doctorID,
+			"appointmentDate": tomorrowAt(cfg, 9),
+		})
+	}
+
+	var first, second WalkInResponse
+	rec := walkIn(9)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("walk-in pertama: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&first)
+	if !first.PatientCreated {
+		t.Error("walk-in pertama seharusnya membuat pasien baru")
+	}
+
+	rec = walkIn(10)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("walk-in kedua: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&second)
+	if second.PatientCreated || second.Patient.ID != first.Patient.ID {
+		t.Errorf("walk-in kedua membuat pasien %d (created=%v), want pasien lama %d", second.Patient.ID, second.PatientCreated, first.Patient.ID)
+	}
+
+	if rec := walkIn(9); rec.Code != http.StatusConflict {
+		t.Fatalf("walk-in pada slot yang sudah terisi: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestCreateAppointmentDailyLimit(t *testing.T) {
+	pool := testPool(t, nil)
+	tests := []struct {
+		name       string
+		limit      int
+		wantSecond int
+	}{
+		{name: "tanpa batas (default)", limit: 0, wantSecond: http.StatusCreated},
+		{name: "maksimal 1 per hari", limit: 1, wantSecond: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MaxAppointmentsPerPatientPerDay = tt.limit
+			patientID := createTestPatient(t, pool)
+			handler := CreateAppointmentHandler(pool, cfg)
+
+			// Dua dokter berbeda pada hari yang sama: batasnya berlaku di semua dokter
+			first := serveJSON(t, handler, http.MethodPost, "/appointments", map[string]any{
+				"patientId": patientID, "doctorId": createTestDoctor(t, pool), "appointmentDate": tomorrowAt(cfg, 9),
+			})
+			if first.Code != http.StatusCreated {
+				t.Fatalf("janji temu pertama: status = %d, want 201 (%s)", first.Code, first.Body)
+			}
+			second := serveJSON(t, handler, http.MethodPost, "/appointments", map[string]any{
+				"patientId": patientID, "doctorId": createTestDoctor(t, pool), "appointmentDate": tomorrowAt(cfg, 11),
+			})
+			if second.Code != tt.wantSecond {
+				t.Fatalf("janji temu kedua: status = %d, want %d (%s)", second.Code, tt.wantSecond, second.Body)
+			}
+		})
+	}
+}
+
+func TestCreateAppointmentUsesDefaultStatus(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.DefaultAppointmentStatus = StatusRescheduled
+
+	rec := serveJSON(t, CreateAppointmentHandler(pool, cfg), http.MethodPost, "/appointments", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": createTestDoctor(t, pool), "appointmentDate": tomorrowAt(cfg, 9),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	var appt Appointment
+	json.NewDecoder(rec.Body).Decode(&appt)
+	if appt.Status != StatusRescheduled {
+		t.Fatalf("status janji temu = %q, want %q", appt.Status, StatusRescheduled)
+	}
+}
+
+// rescheduleRoute adalah pola route RescheduleAppointmentHandler di cmd/api/main.go.
+const rescheduleRoute = "PATCH /appointments/{id}"
+
+func TestRescheduleMinimumNotice(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.MinRescheduleNotice = time.Hour
+	cfg.AdminAPIKey = "rahasia"
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	handler := routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg))
+
+	tests := []struct {
+		name    string
+		current time.Time
+		target  time.Time
+		admin   bool
+		want    int
+	}{
+		{name: "di luar batas waktu", current: tomorrowAt(cfg, 9), target: tomorrowAt(cfg, 10), want: http.StatusOK},
+		{name: "di dalam batas waktu", current: time.Now().Add(30 * time.Minute), target: tomorrowAt(cfg, 11), want: http.StatusConflict},
+		{name: "di dalam batas waktu oleh admin", current: time.Now().Add(31 * time.Minute), target: tomorrowAt(cfg, 12), admin: true, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := insertTestAppointment(t, pool, patientID, doctorID, tt.current, StatusConfirmed)
+			req := newJSONRequest(t, http.MethodPatch, fmt.Sprintf("/appointments/%d", id), RescheduleRequest{
+				NewAppointmentDate: tt.target,
+			})
+			if tt.admin {
+				req.Header.Set("X-Admin-Key", cfg.AdminAPIKey)
+			}
+			if rec := serve(handler, req); rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRescheduleToSameTimeReturns422(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	date := tomorrowAt(cfg, 9)
+	id := insertTestAppointment(t, pool, createTestPatient(t, pool), createTestDoctor(t, pool), date, StatusConfirmed)
+
+	// Offset berbeda, tetapi waktunya sama persis
+	target := date.In(time.FixedZone("WIB", 7*60*60))
+	rec := serveJSON(t, routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg)), http.MethodPatch,
+		fmt.Sprintf("/appointments/%d", id), RescheduleRequest{NewAppointmentDate: target})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestRescheduleWithinSameDayDoesNotConflictWithItself(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	id := insertTestAppointment(t, pool, createTestPatient(t, pool), createTestDoctor(t, pool), tomorrowAt(cfg, 9), StatusConfirmed)
+
+	// Jadwal lama (09:00) masih tersimpan saat jadwal baru divalidasi; janji
+	// temu itu sendiri tidak boleh dianggap bentrok.
+	target := tomorrowAt(cfg, 9).Add(cfg.SlotDuration)
+	rec := serveJSON(t, routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg)), http.MethodPatch,
+		fmt.Sprintf("/appointments/%d", id), RescheduleRequest{NewAppointmentDate: target})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	appt := decodeJSON[Appointment](t, rec)
+	if !appt.AppointmentDate.Equal(target) || appt.Status != StatusRescheduled {
+		t.Fatalf("janji temu = %+v, want RESCHEDULED pada %s", appt, target)
+	}
+}
+
+func TestGetAppointmentsByPatientFiltersBySpecialty(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cardiologist := createTestDoctor(t, pool)
+	general := createTestDoctor(t, pool)
+	cardiology := addTestSpecialty(t, pool, cardiologist)
+	// Spesialisasi yang dikenal tetapi tidak pernah dikunjungi pasien
+	unvisited := addTestSpecialty(t, pool, createTestDoctor(t, pool))
+
+	patientID := createTestPatient(t, pool)
+	cardioVisit := insertTestAppointment(t, pool, patientID, cardiologist, tomorrowAt(cfg, 9), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, general, tomorrowAt(cfg, 10), StatusConfirmed)
+	handler := routed("GET /patients/{id}/appointments", GetAppointmentsByPatientIDHandler(pool, cfg))
+
+	list := func(specialty string) *httptest.ResponseRecorder {
+		target := fmt.Sprintf("/patients/%d/appointments?specialty=%s", patientID, url.QueryEscape(specialty))
+		return serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	for _, tc := range []struct {
+		specialty string
+		want      []int
+	}{
+		{cardiology, []int{cardioVisit}},
+		{unvisited, nil},
+	} {
+		rec := list(tc.specialty)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("specialty=%s: status = %d, want 200 (%s)", tc.specialty, rec.Code, rec.Body)
+		}
+		var ids []int
+		for _, a := range decodeJSON[[]AppointmentResponse](t, rec) {
+			ids = append(ids, a.ID)
+		}
+		if !slices.Equal(ids, tc.want) {
+			t.Errorf("specialty=%s: janji temu = %v, want %v", tc.specialty, ids, tc.want)
+		}
+	}
+
+	if rec := list("Spesialisasi Tidak Ada"); rec.Code != http.StatusBadRequest {
+		t.Errorf("specialty tidak dikenal: status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestRescheduleRespectsConfiguredStatuses(t *testing.T) {
+	pool := testPool(t, nil)
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+
+	tests := []struct {
+		name     string
+		statuses []string
+		hour     int
+		want     int
+	}{
+		{name: "CHECKED_IN tidak diizinkan (default)", statuses: []string{StatusConfirmed, StatusRescheduled}, hour: 9, want: http.StatusConflict},
+		{name: "CHECKED_IN diizinkan", statuses: []string{StatusConfirmed, StatusRescheduled, StatusCheckedIn}, hour: 11, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.ReschedulableStatuses = tt.statuses
+			id := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, tt.hour), StatusCheckedIn)
+			rec := serveJSON(t, routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg)), http.MethodPatch,
+				fmt.Sprintf("/appointments/%d", id), RescheduleRequest{NewAppointmentDate: tomorrowAt(cfg, tt.hour+1)})
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+const patchPatientRoute = "PATCH /patients/{id}"
+
+func TestPatchPatientUpdatesOnlySentFields(t *testing.T) {
+	pool := testPool(t, nil)
+	patientID := createTestPatient(t, pool)
+	var ktp string
+	if err := pool.QueryRow(context.Background(), "SELECT ktp_number FROM patients WHERE id = $1", patientID).Scan(&ktp); err != nil {
+		t.Fatalf("Gagal mengambil KTP pasien test: %v", err)
+	}
+	handler := routed(patchPatientRoute, PatchPatientHandler(pool, testConfig(), nil))
+	target := fmt.Sprintf("/patients/%d", patientID)
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want Patient
+	}{
+		{name: "hanya nama", body: map[string]any{"fullName": "Budi Santoso"},
+			want: Patient{ID: patientID, KTPNumber: ktp, FullName: "Budi Santoso", DateOfBirth: "01-01-1990"}},
+		{name: "hanya tanggal lahir", body: map[string]any{"dateOfBirth": "15-08-1985"},
+			want: Patient{ID: patientID, KTPNumber: ktp, FullName: "Budi Santoso", DateOfBirth: "15-08-1985"}},
+	}
+	for _, tt := range tests {
+		rec := serveJSON(t, handler, http.MethodPatch, target, tt.body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", tt.name, rec.Code, rec.Body)
+		}
+		got := decodeJSON[Patient](t, rec)
+		if got.ID != tt.want.ID || got.KTPNumber != tt.want.KTPNumber || got.FullName != tt.want.FullName || got.DateOfBirth != tt.want.DateOfBirth {
+			t.Errorf("%s: pasien = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPatchPatientWithoutFieldsReturns400(t *testing.T) {
+	// Body kosong ditolak sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := routed(patchPatientRoute, PatchPatientHandler(nil, testConfig(), nil))
+	if rec := serveJSON(t, handler, http.MethodPatch, "/patients/1", map[string]any{}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+}
+
+const timeOffRoute = "POST /doctors/{id}/timeoff"
+
+func TestAddDoctorTimeOffDuplicateDate(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	handler := routed(timeOffRoute, AddDoctorTimeOffHandler(pool, cfg))
+	tomorrow := tomorrowAt(cfg, 0)
+
+	tests := []struct {
+		name  string
+		date  time.Time
+		query string
+		want  int
+	}{
+		{name: "tanggal baru", date: tomorrow, want: http.StatusCreated},
+		{name: "tanggal sama tanpa idempotent", date: tomorrow, want: http.StatusConflict},
+		{name: "tanggal sama dengan idempotent", date: tomorrow, query: "?idempotent=true", want: http.StatusOK},
+		{name: "tanggal baru dengan idempotent", date: tomorrow.AddDate(0, 0, 1), query: "?idempotent=true", want: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/timeoff%s", doctorID, tt.query),
+			TimeOffRequest{OffDate: tt.date.Format(dateLayout), Reason: "Cuti"})
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	var count int
+	pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM doctor_time_off WHERE doctor_id = $1", doctorID).Scan(&count)
+	if count != 2 {
+		t.Errorf("jumlah tanggal libur = %d, want 2", count)
+	}
+}
+
+func TestAddDoctorTimeOffReasonLength(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	add := routed(timeOffRoute, AddDoctorTimeOffHandler(pool, cfg))
+	date := tomorrowAt(cfg, 0).Format(dateLayout)
+
+	rec := serveJSON(t, add, http.MethodPost, fmt.Sprintf("/doctors/%d/timeoff", doctorID),
+		TimeOffRequest{OffDate: date, Reason: strings.Repeat("a", maxReasonLength+1)})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("alasan terlalu panjang: status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, add, http.MethodPost, fmt.Sprintf("/doctors/%d/timeoff", doctorID),
+		TimeOffRequest{OffDate: date, Reason: "  Seminar kedokteran "})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("alasan normal: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+
+	list := routed("GET /doctors/{id}/timeoff", GetDoctorTimeOffHandler(pool, cfg))
+	rec = serve(list, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/doctors/%d/timeoff", doctorID), nil))
+	got := decodeJSON[[]TimeOffResponse](t, rec)
+	if len(got) != 1 || got[0].OffDate != date || got[0].Reason == nil || *got[0].Reason != "Seminar kedokteran" {
+		t.Errorf("tanggal libur = %+v, want satu tanggal %s dengan alasan yang sudah di-trim", got, date)
+	}
+}
+
+func TestAddDoctorTimeOffCancelsConflicts(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	handler := routed(timeOffRoute, AddDoctorTimeOffHandler(pool, cfg))
+	offDay := tomorrowAt(cfg, 0)
+	nextDay := offDay.AddDate(0, 0, 1)
+
+	confirmed := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	rescheduled := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 10), StatusRescheduled)
+	insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusCancelled)
+	otherDay := insertTestAppointment(t, pool, patientID, doctorID, nextDay.Add(9*time.Hour), StatusConfirmed)
+
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/timeoff?cancelConflicts=true", doctorID),
+		TimeOffRequest{OffDate: offDay.Format(dateLayout)})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	var resp struct {
+		CancelledAppointmentIDs []int `json:"cancelledAppointmentIds"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Body response tidak valid: %v", err)
+	}
+	slices.Sort(resp.CancelledAppointmentIDs)
+	if want := []int{confirmed, rescheduled}; !slices.Equal(resp.CancelledAppointmentIDs, want) {
+		t.Errorf("cancelledAppointmentIds = %v, want %v", resp.CancelledAppointmentIDs, want)
+	}
+	if got := appointmentStatus(pool, otherDay); got != StatusConfirmed {
+		t.Errorf("janji temu di hari lain: status = %s, want %s", got, StatusConfirmed)
+	}
+
+	// Tanpa cancelConflicts, janji temu pada tanggal libur dibiarkan.
+	rec = serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/timeoff", doctorID),
+		TimeOffRequest{OffDate: nextDay.Format(dateLayout)})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("tanpa cancelConflicts: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	if got := appointmentStatus(pool, otherDay); got != StatusConfirmed {
+		t.Errorf("tanpa cancelConflicts: status = %s, want %s", got, StatusConfirmed)
+	}
+}
+
+const rescheduleNextRoute = "POST /appointments/{id}/reschedule-next"
+
+func TestRescheduleToNextSlot(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	handler := routed(rescheduleNextRoute, RescheduleToNextSlotHandler(pool, cfg))
+
+	tests := []struct {
+		name  string
+		taken bool
+		want  time.Time
+	}{
+		{name: "slot berikutnya kosong", want: tomorrowAt(cfg, 9).Add(cfg.SlotDuration)},
+		{name: "slot berikutnya sudah terisi", taken: true, want: tomorrowAt(cfg, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doctorID := createTestDoctor(t, pool)
+			id := insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+			if tt.taken {
+				insertTestAppointment(t, pool, createTestPatient(t, pool), doctorID, tomorrowAt(cfg, 9).Add(cfg.SlotDuration), StatusConfirmed)
+			}
+
+			rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/appointments/%d/reschedule-next", id), nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+			}
+			appt := decodeJSON[Appointment](t, rec)
+			if !appt.AppointmentDate.Equal(tt.want) || appt.Status != StatusRescheduled {
+				t.Fatalf("janji temu = %+v, want RESCHEDULED pada %s", appt, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingAgainstInactivePatient(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	create := CreateAppointmentHandler(pool, cfg)
+	book := func() *httptest.ResponseRecorder {
+		return serveJSON(t, create, http.MethodPost, "/appointments", map[string]any{
+			"patientId": patientID, "doctorId": doctorID, "appointmentDate": tomorrowAt(cfg, 9),
+		})
+	}
+
+	rec := serveJSON(t, routed("PATCH /patients/{id}/deactivate", SetPatientActiveHandler(pool, false)), http.MethodPatch,
+		fmt.Sprintf("/patients/%d/deactivate", patientID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("deactivate: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if p := decodeJSON[Patient](t, rec); p.Active {
+		t.Fatalf("deactivate: active = true, want false")
+	}
+	if rec := book(); rec.Code != http.StatusConflict {
+		t.Fatalf("booking pasien nonaktif: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, routed("PATCH /patients/{id}/activate", SetPatientActiveHandler(pool, true)), http.MethodPatch,
+		fmt.Sprintf("/patients/%d/activate", patientID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("activate: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if rec := book(); rec.Code != http.StatusCreated {
+		t.Fatalf("booking setelah diaktifkan kembali: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestPatientIdentityModesWithCollidingKTP(t *testing.T) {
+	pool := testPool(t, nil)
+	existing := createTestPatient(t, pool) // tanggal lahir 1990-01-01
+	var ktp string
+	if err := pool.QueryRow(context.Background(), "SELECT ktp_number FROM patients WHERE id = $1", existing).Scan(&ktp); err != nil {
+		t.Fatalf("Gagal membaca KTP pasien test: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		uniqueKTPDOB bool
+		dob          time.Time
+		want         int
+	}{
+		{name: "KTP saja, tanggal lahir berbeda", dob: time.Date(1991, 2, 3, 0, 0, 0, 0, time.UTC), want: http.StatusConflict},
+		{name: "KTP + tanggal lahir, tanggal lahir berbeda", uniqueKTPDOB: true, dob: time.Date(1991, 2, 3, 0, 0, 0, 0, time.UTC), want: 0},
+		{name: "KTP + tanggal lahir, tanggal lahir sama", uniqueKTPDOB: true, dob: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.PatientUniqueKTPAndDOB = tt.uniqueKTPDOB
+			err := checkPatientIdentity(context.Background(), pool, ktp, tt.dob, 0, cfg)
+			if got := slotErrorStatus(err); got != tt.want {
+				t.Fatalf("status = %d, want %d (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+
+	// Lewat handler pada mode default: KTP yang sama ditolak walaupun tanggal lahirnya berbeda
+	rec := serveJSON(t, CreatePatientHandler(pool, testConfig(), nil), http.MethodPost, "/patients", Patient{
+		KTPNumber: ktp, FullName: "Pasien Lain", DateOfBirth: "03-02-1991",
+	})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("pasien baru dengan KTP yang sama: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), uniqueConstraintMessages["patients_ktp_number_key"]; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestRescheduleInactivePatientReturns409(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	patientID := createTestPatient(t, pool)
+	doctorID := createTestDoctor(t, pool)
+	id := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	execSQL(t, pool, "UPDATE patients SET active = false WHERE id = $1", patientID)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		req     *http.Request
+	}{
+		{
+			name:    "jadwal ulang ke jam tertentu",
+			handler: routed(rescheduleRoute, RescheduleAppointmentHandler(pool, cfg)),
+			req:     newJSONRequest(t, http.MethodPatch, fmt.Sprintf("/appointments/%d", id), RescheduleRequest{NewAppointmentDate: tomorrowAt(cfg, 10)}),
+		},
+		{
+			name:    "jadwal ulang ke slot berikutnya",
+			handler: routed(rescheduleNextRoute, RescheduleToNextSlotHandler(pool, cfg)),
+			req:     newJSONRequest(t, http.MethodPost, fmt.Sprintf("/appointments/%d/reschedule-next", id), nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, tt.req)
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want 409 (%s)", rec.Code, rec.Body)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != inactivePatientRescheduleMessage {
+				t.Fatalf("body = %q, want %q", got, inactivePatientRescheduleMessage)
+			}
+		})
+	}
+
+	// Jadwal lama tidak berubah
+	var date time.Time
+	pool.QueryRow(context.Background(), "SELECT appointment_date FROM appointments WHERE id = $1", id).Scan(&date)
+	if !date.Equal(tomorrowAt(cfg, 9)) {
+		t.Errorf("appointment_date = %s, want tetap %s", date, tomorrowAt(cfg, 9))
+	}
+}
+
+func TestCreateAppointmentMissingPatientReturns404(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	execSQL(t, pool, "DELETE FROM patients WHERE id = $1", patientID)
+
+	// Jam 20:00 di luar jam praktik: pasien harus dicek lebih dulu (404),
+	// bukan gagal di pengecekan jadwal (409).
+	rec := serveJSON(t, CreateAppointmentHandler(pool, cfg), http.MethodPost, "/appointments", map[string]any{
+		"patientId": patientID, "doctorId": doctorID, "appointmentDate": tomorrowAt(cfg, 20),
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), "Pasien dengan ID tersebut tidak ditemukan."; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
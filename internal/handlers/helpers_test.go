@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool membuka connection pool ke database test dari TEST_DATABASE_URL
+// (database yang sudah menjalankan semua file di migrations/). Test dilewati
+// jika variabel tersebut tidak diisi. configure, jika ada, dipanggil sebelum
+// pool dibuat, mis. untuk mengecilkan MaxConns.
+func testPool(t *testing.T, configure func(*pgxpool.Config)) *pgxpool.Pool {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL tidak diisi, test database dilewati")
+	}
+	poolConfig, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL tidak valid: %v", err)
+	}
+	if configure != nil {
+		configure(poolConfig)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatalf("Gagal membuat pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// testConfig mengembalikan konfigurasi yang sama dengan default config.Load
+// tanpa membaca environment, dengan zona waktu UTC.
+func testConfig() *config.Config {
+	return &config.Config{
+		Location:                 time.UTC,
+		DBAcquireTimeout:         3 * time.Second,
+		DoctorNIKRequired:        true,
+		RescheduleSearchDays:     14,
+		ReminderLeadTime:         24 * time.Hour,
+		ConfirmationWindow:       24 * time.Hour,
+		DoctorCacheTTL:           60 * time.Second,
+		LogSampleRate:            1,
+		DefaultAppointmentStatus: StatusConfirmed,
+		ReschedulableStatuses:    []string{StatusConfirmed, StatusRescheduled},
+		SlotDuration:             30 * time.Minute,
+		HoldTTL:                  5 * time.Minute,
+		DefaultPageSize:          20,
+		MaxPageSize:              100,
+	}
+}
+
+// createTestDoctor menyimpan dokter dengan NIK acak yang praktik setiap hari
+// pukul 08:00-16:00. Dokter dan datanya dihapus setelah test selesai.
+func createTestDoctor(t *testing.T, pool *pgxpool.Pool) int {
+	t.Helper()
+	ctx := context.Background()
+	var id int
+	err := pool.QueryRow(ctx, "INSERT INTO doctors (nik, name, specialty) VALUES ($1, 'Dokter Test', 'Umum') RETURNING id",
+		fmt.Sprintf("%010d", rand.Int64N(1e10))).Scan(&id)
+	if err != nil {
+		t.Fatalf("Gagal membuat dokter test: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, q := range []string{
+			"DELETE FROM appointments WHERE doctor_id = $1",
+			"DELETE FROM doctor_schedules WHERE doctor_id = $1",
+			"DELETE FROM doctor_time_off WHERE doctor_id = $1",
+			"DELETE FROM doctor_specialty_audit WHERE doctor_id = $1",
+			"DELETE FROM doctors WHERE id = $1",
+		} {
+			pool.Exec(ctx, q, id)
+		}
+	})
+	execSQL(t, pool, "INSERT INTO doctor_specialties (doctor_id, specialty) VALUES ($1, 'Umum')", id)
+	for day := 1; day <= 7; day++ {
+		_, err := pool.Exec(ctx, "INSERT INTO doctor_schedules (doctor_id, day_of_week, start_time, end_time) VALUES ($1, $2, '08:00', '16:00')", id, day)
+		if err != nil {
+			t.Fatalf("Gagal membuat jadwal dokter test: %v", err)
+		}
+	}
+	return id
+}
+
+// createTestPatient menyimpan pasien dengan KTP acak. Pasien beserta janji
+// temu dan dokumennya dihapus setelah test selesai.
+func createTestPatient(t *testing.T, pool *pgxpool.Pool) int {
+	t.Helper()
+	ctx := context.Background()
+	var id int
+	err := pool.QueryRow(ctx, "INSERT INTO patients (ktp_number, full_name, date_of_birth) VALUES ($1, 'Pasien Test', '1990-01-01') RETURNING id",
+		randomKTP()).Scan(&id)
+	if err != nil {
+		t.Fatalf("Gagal membuat pasien test: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(ctx, "DELETE FROM appointments WHERE patient_id = $1", id)
+		pool.Exec(ctx, "DELETE FROM patient_documents WHERE patient_id = $1", id)
+		pool.Exec(ctx, "DELETE FROM patients WHERE id = $1", id)
+	})
+	return id
+}
+
+// insertTestAppointment menyimpan janji temu langsung ke database tanpa
+// pengecekan jadwal, untuk menyiapkan data test. Janji temu ikut dihapus
+// oleh cleanup createTestDoctor/createTestPatient.
+func insertTestAppointment(t *testing.T, pool *pgxpool.Pool, patientID, doctorID int, date time.Time, status string) int {
+	t.Helper()
+	var id int
+	err := pool.QueryRow(context.Background(), "INSERT INTO appointments (patient_id, doctor_id, appointment_date, status) VALUES ($1, $2, $3, $4) RETURNING id",
+		patientID, doctorID, date, status).Scan(&id)
+	if err != nil {
+		t.Fatalf("Gagal membuat janji temu test: %v", err)
+	}
+	return id
+}
+
+// appointmentStatus mengembalikan status janji temu id, atau string kosong
+// jika barisnya sudah tidak ada.
+func appointmentStatus(pool *pgxpool.Pool, id int) string {
+	var status string
+	pool.QueryRow(context.Background(), "SELECT status FROM appointments WHERE id = $1", id).Scan(&status)
+	return status
+}
+
+// appointmentIDs mengembalikan ID setiap janji temu sesuai urutannya.
+func appointmentIDs(appointments []Appointment) []int {
+	ids := make([]int, len(appointments))
+	for i, a := range appointments {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+// execSQL menjalankan perintah SQL untuk menyiapkan data test.
+func execSQL(t *testing.T, pool *pgxpool.Pool, sql string, args ...any) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("Gagal menjalankan %q: %v", sql, err)
+	}
+}
+
+// addTestSpecialty menambahkan spesialisasi acak ke setiap dokter di
+// doctorIDs dan mengembalikannya, agar endpoint yang memfilter per
+// spesialisasi hanya mengembalikan dokter test tersebut.
+func addTestSpecialty(t *testing.T, pool *pgxpool.Pool, doctorIDs ...int) string {
+	t.Helper()
+	specialty := fmt.Sprintf("Spesialis Test %d", rand.Int64N(1e9))
+	for _, id := range doctorIDs {
+		execSQL(t, pool, "INSERT INTO doctor_specialties (doctor_id, specialty) VALUES ($1, $2)", id, specialty)
+	}
+	return specialty
+}
+
+// zoneWithLocalHour mengembalikan zona waktu Etc/GMT±N yang jam lokalnya saat
+// ini adalah hour, agar test yang bergantung pada "hari ini" tidak dipengaruhi
+// jam berapa test dijalankan.
+func zoneWithLocalHour(t *testing.T, hour int) *time.Location {
+	t.Helper()
+	offset := (hour - time.Now().UTC().Hour() + 24) % 24
+	if offset > 12 {
+		offset -= 24
+	}
+	// Tanda Etc/GMT terbalik: Etc/GMT-7 adalah UTC+7.
+	name := "Etc/GMT"
+	if offset > 0 {
+		name = fmt.Sprintf("Etc/GMT-%d", offset)
+	} else if offset < 0 {
+		name = fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("Gagal memuat zona waktu %s: %v", name, err)
+	}
+	return loc
+}
+
+// randomKTP mengembalikan nomor KTP 16 digit acak.
+func randomKTP() string {
+	return fmt.Sprintf("%016d", rand.Int64N(1e16))
+}
+
+// tomorrowAt mengembalikan pukul hour:00 besok menurut zona waktu cfg.
+func tomorrowAt(cfg *config.Config, hour int) time.Time {
+	now := time.Now().In(cfg.Location)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, hour, 0, 0, 0, cfg.Location)
+}
+
+// newJSONRequest membuat request dengan body berupa body yang di-encode ke
+// JSON (nil berarti tanpa body) dan Content-Type application/json.
+func newJSONRequest(t *testing.T, method, target string, body any) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("Gagal meng-encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// serve menjalankan handler untuk req dan mengembalikan response-nya.
+func serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveJSON menjalankan handler dengan body berupa body yang di-encode ke JSON
+// (nil berarti tanpa body) dan mengembalikan response-nya.
+func serveJSON(t *testing.T, handler http.Handler, method, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(handler, newJSONRequest(t, method, target, body))
+}
+
+// routed memasang handler pada pola route seperti di cmd/api/main.go agar
+// r.PathValue terisi, mis. routed("GET /patients/{id}", h).
+func routed(pattern string, handler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, handler)
+	return mux
+}
+
+// decodeJSON mendekode body response ke T.
+func decodeJSON[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("Body response tidak valid: %v", err)
+	}
+	return v
+}
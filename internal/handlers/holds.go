@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// expireStaleHolds menghapus janji temu berstatus HELD yang sudah melewati
+// hold_expires_at, sehingga slotnya bisa dipesan lagi.
+func expireStaleHolds(ctx context.Context, dbpool querier) error {
+	_, err := dbpool.Exec(ctx, "DELETE FROM appointments WHERE status = $1 AND hold_expires_at <= NOW()", StatusHeld)
+	return err
+}
+
+// HoldAppointmentHandler menahan (hold) sebuah slot selama cfg.HoldTTL
+// sementara pasien menyelesaikan proses booking, misalnya pembayaran.
+// Slot yang di-hold tidak ditawarkan ke pasien lain sampai dikonfirmasi
+// lewat ConfirmAppointmentHandler atau kedaluwarsa.
+func HoldAppointmentHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Dekode request JSON
+		var appt Appointment
+		if err := json.NewDecoder(r.Body).Decode(&appt); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if err := validateAppointmentRequest(appt); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if err := validatePriority(&appt.Priority); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// 2. Validasi pasien dan jadwal sama seperti pembuatan janji temu biasa
+		if err := checkAppointmentParties(r.Context(), dbpool, appt.PatientID, appt.DoctorID); err != nil {
+			writeSlotError(w, err)
+			return
+		}
+		err := validateAppointmentSlot(r.Context(), dbpool, slotCheck{
+			DoctorID:  appt.DoctorID,
+			PatientID: appt.PatientID,
+			Date:      appt.AppointmentDate.Time,
+		}, cfg)
+		if err != nil {
+			writeSlotError(w, err)
+			return
+		}
+		if !isAdmin(r, cfg) {
+			if err := checkPatientDailyLimit(r.Context(), dbpool, appt.PatientID, appt.DoctorID, appt.AppointmentDate.Time, cfg); err != nil {
+				writeSlotError(w, err)
+				return
+			}
+		}
+
+		if err := expireStaleHolds(r.Context(), dbpool); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
+
+		// 3. Simpan sebagai HELD dengan batas waktu
+		query := `INSERT INTO appointments (patient_id, doctor_id, appointment_date, status, hold_expires_at, created_by, priority)
+                  VALUES ($1, $2, $3, $4, NOW() + $5::interval, $6, $7)
+                  RETURNING ` + appointmentColumns
+
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, appt.PatientID, appt.DoctorID, appt.AppointmentDate, StatusHeld, cfg.HoldTTL, actorID(r), appt.Priority), &appt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				switch pgErr.Code {
+				case "23505":
+					http.Error(w, constraintMessage(pgErr), http.StatusConflict)
+					return
+				case "23503":
+					http.Error(w, "Patient atau Doctor dengan ID tersebut tidak ditemukan.", http.StatusNotFound)
+					return
+				}
+			}
+			log.Printf("Gagal menyimpan hold janji temu: %v", err)
+			http.Error(w, "Gagal menyimpan hold janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(appt)
+	}
+}
+
+// ConfirmAppointmentHandler memfinalisasi slot yang sedang di-hold menjadi
+// janji temu CONFIRMED, selama hold-nya belum kedaluwarsa.
+func ConfirmAppointmentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appointmentID := r.PathValue("id")
+
+		query := `UPDATE appointments SET status = $2, hold_expires_at = NULL
+                  WHERE id = $1 AND status = $3 AND hold_expires_at > NOW()
+                  RETURNING ` + appointmentColumns
+
+		var appt Appointment
+		err := scanAppointment(dbpool.QueryRow(r.Context(), query, appointmentID, StatusConfirmed, StatusHeld), &appt)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(appt)
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Gagal mengonfirmasi janji temu: %v", err)
+			http.Error(w, "Gagal mengonfirmasi janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// Tidak ada baris yang diupdate: bedakan antara tidak ada dan hold yang sudah tidak berlaku
+		var exists bool
+		if err := dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM appointments WHERE id = $1)", appointmentID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Janji temu tidak sedang di-hold atau hold sudah kedaluwarsa.", http.StatusConflict)
+	}
+}
+
+// ConfirmAttendanceHandler mencatat konfirmasi kehadiran pasien di
+// confirmed_at (POST /appointments/{id}/attendance), untuk janji temu yang
+// sudah terjadwal (CONFIRMED/RESCHEDULED) dan belum dimulai. Konfirmasi
+// berulang mempertahankan waktu konfirmasi pertama.
+func ConfirmAttendanceHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		query := `UPDATE appointments SET confirmed_at = COALESCE(confirmed_at, NOW())
+                  WHERE id = $1 AND status IN ($2, $3) AND appointment_date > NOW()
+                  RETURNING ` + appointmentColumns
+
+		var appt Appointment
+		err = scanAppointment(dbpool.QueryRow(r.Context(), query, appointmentID, StatusConfirmed, StatusRescheduled), &appt)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(appt)
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Gagal mencatat konfirmasi kehadiran: %v", err)
+			http.Error(w, "Gagal mencatat konfirmasi kehadiran", http.StatusInternalServerError)
+			return
+		}
+
+		// Tidak ada baris yang diupdate: bedakan antara tidak ada dan tidak bisa dikonfirmasi
+		var exists bool
+		if err := dbpool.QueryRow(r.Context(), "SELECT EXISTS (SELECT 1 FROM appointments WHERE id = $1)", appointmentID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Kehadiran tidak dapat dikonfirmasi: janji temu sudah lewat atau statusnya tidak terjadwal.", http.StatusConflict)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const confirmRoute = "POST /appointments/{id}/confirm"
+
+func TestHoldBlocksSlotUntilConfirmed(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	date := tomorrowAt(cfg, 9)
+
+	rec := serveJSON(t, HoldAppointmentHandler(pool, cfg), http.MethodPost, "/appointments/hold", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": date,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("hold: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	held := decodeJSON[Appointment](t, rec)
+	if held.Status != StatusHeld || held.HoldExpiresAt == nil {
+		t.Fatalf("hold: status = %s, holdExpiresAt = %v, want HELD dengan batas waktu", held.Status, held.HoldExpiresAt)
+	}
+
+	rec = serveJSON(t, CreateAppointmentHandler(pool, cfg), http.MethodPost, "/appointments", map[string]any{
+		"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": date,
+	})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("booking pada slot yang di-hold: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, routed(confirmRoute, ConfirmAppointmentHandler(pool)), http.MethodPost, fmt.Sprintf("/appointments/%d/confirm", held.ID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirm: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	confirmed := decodeJSON[Appointment](t, rec)
+	if confirmed.Status != StatusConfirmed || confirmed.HoldExpiresAt != nil {
+		t.Errorf("confirm: status = %s, holdExpiresAt = %v, want CONFIRMED tanpa batas waktu", confirmed.Status, confirmed.HoldExpiresAt)
+	}
+}
+
+func TestConfirmExpiredHoldReturns409(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	id := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusHeld)
+	execSQL(t, pool, "UPDATE appointments SET hold_expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", id)
+
+	handler := routed(confirmRoute, ConfirmAppointmentHandler(pool))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/appointments/%d/confirm", id), nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("confirm hold kedaluwarsa: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, handler, http.MethodPost, "/appointments/0/confirm", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("confirm janji temu yang tidak ada: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
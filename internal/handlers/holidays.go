@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Holiday adalah hari libur nasional; klinik tutup untuk semua dokter.
+type Holiday struct {
+	ID   int    `json:"id"`
+	Date string `json:"date"` // Format: YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+// isHoliday mengecek apakah tanggal (menurut zona waktu aplikasi) adalah hari libur nasional.
+func isHoliday(ctx context.Context, dbpool querier, date time.Time, loc *time.Location) (bool, error) {
+	var holiday bool
+	err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM holidays WHERE holiday_date = $1)", date.In(loc).Format(dateLayout)).Scan(&holiday)
+	return holiday, err
+}
+
+// GetHolidaysHandler mengembalikan daftar hari libur, diurutkan dari tanggal
+// terdekat. Filter opsional ?from= dan ?to= (YYYY-MM-DD, inklusif).
+func GetHolidaysHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var from, to *string
+		if dr.From != nil {
+			s := dr.From.Format(dateLayout)
+			from = &s
+		}
+		if dr.To != nil {
+			s := dr.To.Format(dateLayout)
+			to = &s
+		}
+
+		query := `SELECT id, holiday_date, name FROM holidays
+                  WHERE ($1::date IS NULL OR holiday_date >= $1::date)
+                  AND ($2::date IS NULL OR holiday_date <= $2::date)
+                  ORDER BY holiday_date`
+
+		holidays := []Holiday{}
+		err = withRetry(r.Context(), func() error {
+			holidays = holidays[:0]
+			rows, err := dbpool.Query(r.Context(), query, from, to)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var h Holiday
+				var date time.Time
+				if err := rows.Scan(&h.ID, &date, &h.Name); err != nil {
+					return err
+				}
+				h.Date = date.Format(dateLayout)
+				holidays = append(holidays, h)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data hari libur", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(holidays)
+	}
+}
+
+// CreateHolidayHandler menambahkan satu hari libur nasional. Sejak saat itu
+// booking pada tanggal tersebut ditolak untuk semua dokter.
+func CreateHolidayHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Dekode dan validasi body
+		var h Holiday
+		if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		var verr ValidationErrors
+		date, err := time.Parse(dateLayout, h.Date)
+		if err != nil {
+			verr.add("date", "Format tanggal harus YYYY-MM-DD")
+		}
+		h.Name = strings.TrimSpace(h.Name)
+		if h.Name == "" {
+			verr.add("name", "Nama hari libur tidak boleh kosong.")
+		}
+		if err := verr.err(); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// 2. Simpan ke database
+		err = dbpool.QueryRow(r.Context(), "INSERT INTO holidays (holiday_date, name) VALUES ($1, $2) RETURNING id", date, h.Name).Scan(&h.ID)
+		if err != nil {
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal menyimpan hari libur: %v", err)
+			http.Error(w, "Gagal menyimpan hari libur", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(h)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHolidayBlocksBookingForAllDoctors(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Tanggal jauh di depan agar tidak bentrok dengan hari libur yang sudah ada.
+	holiday := tomorrowAt(cfg, 9).AddDate(3, 0, 0)
+	date := holiday.Format(dateLayout)
+
+	rec := serveJSON(t, CreateHolidayHandler(pool), http.MethodPost, "/holidays", Holiday{Date: date, Name: "Libur Test"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("tambah hari libur: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+	created := decodeJSON[Holiday](t, rec)
+	t.Cleanup(func() { pool.Exec(context.Background(), "DELETE FROM holidays WHERE id = $1", created.ID) })
+
+	rec = serve(GetHolidaysHandler(pool, cfg), httptest.NewRequest(http.MethodGet, "/holidays?from="+date+"&to="+date, nil))
+	if got := decodeJSON[[]Holiday](t, rec); len(got) != 1 || got[0] != created {
+		t.Errorf("GET /holidays = %+v, want [%+v]", got, created)
+	}
+
+	book := CreateAppointmentHandler(pool, cfg)
+	for _, doctorID := range []int{createTestDoctor(t, pool), createTestDoctor(t, pool)} {
+		rec := serveJSON(t, book, http.MethodPost, "/appointments", map[string]any{
+			"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": holiday,
+		})
+		if rec.Code != http.StatusConflict {
+			t.Errorf("dokter %d pada hari libur: status = %d, want 409 (%s)", doctorID, rec.Code, rec.Body)
+		}
+
+		rec = serveJSON(t, book, http.MethodPost, "/appointments", map[string]any{
+			"patientId": createTestPatient(t, pool), "doctorId": doctorID, "appointmentDate": holiday.AddDate(0, 0, 1),
+		})
+		if rec.Code != http.StatusCreated {
+			t.Errorf("dokter %d sehari setelah libur: status = %d, want 201 (%s)", doctorID, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// icsTimeLayout adalah format DATE-TIME iCalendar dalam UTC (RFC 5545).
+const icsTimeLayout = "20060102T150405Z"
+
+// icsEscaper meng-escape karakter khusus pada nilai TEXT iCalendar.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// GetAppointmentICSHandler mengembalikan janji temu sebagai file iCalendar
+// (GET /appointments/{id}.ics) agar pasien bisa menambahkannya ke kalender.
+// Durasi acara sama dengan cfg.SlotDuration.
+//
+// Router net/http tidak mendukung wildcard sebagian segmen ("{id}.ics"), jadi
+// handler ini didaftarkan pada /appointments/{file} dan memeriksa akhiran .ics sendiri.
+func GetAppointmentICSHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Ambil ID janji temu dari nama file
+		idStr, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		appointmentID, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil janji temu beserta nama dokternya
+		var date time.Time
+		var status, doctorName, specialty string
+		query := `SELECT a.appointment_date, a.status, d.name, d.specialty
+                  FROM appointments a
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.id = $1`
+		err = withRetry(r.Context(), func() error {
+			return dbpool.QueryRow(r.Context(), query, appointmentID).Scan(&date, &status, &doctorName, &specialty)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Susun VEVENT (baris diakhiri CRLF sesuai RFC 5545)
+		eventStatus := "CONFIRMED"
+		if status == StatusCancelled {
+			eventStatus = "CANCELLED"
+		}
+		lines := []string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//" + icsEscaper.Replace(cfg.APIName) + "//ID",
+			"CALSCALE:GREGORIAN",
+			"METHOD:PUBLISH",
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:appointment-%d@latihan-api-pasien", appointmentID),
+			"DTSTAMP:" + time.Now().UTC().Format(icsTimeLayout),
+			"DTSTART:" + date.UTC().Format(icsTimeLayout),
+			"DTEND:" + date.Add(cfg.SlotDuration).UTC().Format(icsTimeLayout),
+			"SUMMARY:" + icsEscaper.Replace("Janji temu dengan "+doctorName),
+			"DESCRIPTION:" + icsEscaper.Replace(fmt.Sprintf("Dokter: %s (%s)", doctorName, specialty)),
+			"STATUS:" + eventStatus,
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="appointment-%d.ics"`, appointmentID))
+		w.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const icsRoute = "GET /appointments/{file}"
+
+func TestGetAppointmentICS(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.APIName = "Klinik Test"
+	date := tomorrowAt(cfg, 9)
+	id := insertTestAppointment(t, pool, createTestPatient(t, pool), createTestDoctor(t, pool), date, StatusConfirmed)
+
+	rec := serve(routed(icsRoute, GetAppointmentICSHandler(pool, cfg)), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/appointments/%d.ics", id), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Content-Type = %q, want text/calendar", ct)
+	}
+
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "\r\n") || strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Error("baris ICS harus diakhiri CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n")
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+		t.Errorf("ICS harus diawali BEGIN:VCALENDAR dan diakhiri END:VCALENDAR:\n%s", body)
+	}
+	for _, want := range []string{
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:appointment-%d@latihan-api-pasien", id),
+		"DTSTART:" + date.UTC().Format(icsTimeLayout),
+		"DTEND:" + date.Add(cfg.SlotDuration).UTC().Format(icsTimeLayout),
+		"SUMMARY:Janji temu dengan Dokter Test",
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("baris %q tidak ada di ICS:\n%s", want, body)
+		}
+	}
+}
+
+func TestGetAppointmentICSRejectsOtherFiles(t *testing.T) {
+	// Ditolak sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := routed(icsRoute, GetAppointmentICSHandler(nil, testConfig()))
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/appointments/12.pdf", http.StatusNotFound},
+		{"/appointments/abc.ics", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := serve(handler, httptest.NewRequest(http.MethodGet, tt.target, nil)); rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Batas import janji temu dari CSV.
+const (
+	maxImportRows  = 1000
+	maxImportBytes = 1 << 20
+)
+
+// importColumns adalah kolom wajib di baris header CSV import (urutan bebas).
+var importColumns = []string{"patientKtp", "doctorNik", "date", "status"}
+
+// ImportRowResult adalah hasil import satu baris data CSV. Row dihitung dari 1
+// untuk baris data pertama (baris header tidak dihitung).
+type ImportRowResult struct {
+	Row           int    `json:"row"`
+	AppointmentID int    `json:"appointmentId,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ImportResponse adalah ringkasan import janji temu.
+type ImportResponse struct {
+	Mode     string            `json:"mode"`
+	Inserted int               `json:"inserted"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// ImportAppointmentsHandler memuat janji temu dari sistem lama lewat CSV
+// (POST /appointments/import, khusus admin). Body berupa CSV dengan header
+// patientKtp,doctorNik,date,status; date memakai RFC 3339 dan status kosong
+// berarti cfg.DefaultAppointmentStatus. Setiap baris dicocokkan ke pasien dan
+// dokter lalu divalidasi dengan validateAppointmentSlot (kecuali baris
+// CANCELLED yang tidak menempati slot).
+//
+// Secara default (?mode=all) semua baris disimpan dalam satu transaksi dan
+// tidak ada yang disimpan jika satu baris saja gagal (422). Dengan
+// ?mode=partial, baris yang valid tetap disimpan. Hasil per baris selalu
+// dikembalikan.
+func ImportAppointmentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi mode dan baca CSV
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "all"
+		}
+		if mode != "all" && mode != "partial" {
+			http.Error(w, "mode harus all atau partial", http.StatusBadRequest)
+			return
+		}
+		reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, fmt.Sprintf("Ukuran file CSV maksimal %d byte", maxImportBytes), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "CSV tidak dapat dibaca: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(records) < 2 {
+			http.Error(w, "CSV harus berisi baris header dan minimal satu baris data", http.StatusBadRequest)
+			return
+		}
+		if len(records)-1 > maxImportRows {
+			http.Error(w, fmt.Sprintf("Maksimal %d baris data per import", maxImportRows), http.StatusBadRequest)
+			return
+		}
+		index := map[string]int{}
+		for i, name := range records[0] {
+			index[strings.TrimSpace(name)] = i
+		}
+		for _, name := range importColumns {
+			if _, ok := index[name]; !ok {
+				http.Error(w, fmt.Sprintf("Kolom %s tidak ada di header CSV (wajib: %s)", name, strings.Join(importColumns, ",")), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// 2. Simpan baris per baris; setiap baris memakai savepoint sendiri
+		// sehingga baris yang gagal tidak membatalkan baris lainnya
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		if err := expireStaleHolds(ctx, tx); err != nil {
+			log.Printf("Gagal menghapus hold kedaluwarsa: %v", err)
+		}
+
+		resp := ImportResponse{Mode: mode, Rows: make([]ImportRowResult, 0, len(records)-1)}
+		for i, record := range records[1:] {
+			result := ImportRowResult{Row: i + 1}
+			field := func(name string) string { return strings.TrimSpace(record[index[name]]) }
+
+			id, err := importAppointmentRow(ctx, tx, field("patientKtp"), field("doctorNik"), field("date"), field("status"), actorID(r), cfg)
+			var slotErr *SlotError
+			switch {
+			case errors.As(err, &slotErr):
+				result.Error = slotErr.Message
+			case err != nil:
+				log.Printf("Gagal mengimpor baris %d: %v", i+1, err)
+				http.Error(w, "Gagal mengimpor janji temu", http.StatusInternalServerError)
+				return
+			default:
+				result.AppointmentID = id
+				resp.Inserted++
+			}
+			if result.Error != "" {
+				resp.Failed++
+			}
+			resp.Rows = append(resp.Rows, result)
+		}
+
+		// 3. Commit, kecuali mode all dengan baris yang gagal
+		status := http.StatusOK
+		if mode == "all" && resp.Failed > 0 {
+			resp.Inserted = 0
+			for i := range resp.Rows {
+				resp.Rows[i].AppointmentID = 0
+			}
+			status = http.StatusUnprocessableEntity
+		} else if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit import janji temu: %v", err)
+			http.Error(w, "Gagal mengimpor janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// importAppointmentRow memvalidasi dan menyimpan satu baris import di dalam
+// savepoint pada tx. Baris yang ditolak dikembalikan sebagai *SlotError
+// (savepoint-nya di-rollback); error lain adalah error database.
+func importAppointmentRow(ctx context.Context, tx pgx.Tx, ktp, nik, dateStr, status string, createdBy *string, cfg *config.Config) (int, error) {
+	// 1. Validasi isi baris
+	date, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return 0, &SlotError{http.StatusUnprocessableEntity, "date harus berformat RFC 3339, mis. 2025-01-06T09:00:00+07:00"}
+	}
+	status = strings.ToUpper(status)
+	if status == "" {
+		status = cfg.DefaultAppointmentStatus
+	}
+	if !isKnownStatus(status) || status == StatusHeld {
+		return 0, &SlotError{http.StatusUnprocessableEntity, fmt.Sprintf("Status %q tidak dapat diimpor", status)}
+	}
+
+	// 2. Cocokkan KTP pasien dan NIK dokter
+	var patientIDs []int
+	rows, err := tx.Query(ctx, "SELECT id FROM patients WHERE ktp_number = $1 LIMIT 2", ktp)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		patientIDs = append(patientIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	switch len(patientIDs) {
+	case 0:
+		return 0, &SlotError{http.StatusNotFound, "Pasien dengan KTP tersebut tidak ditemukan"}
+	case 2:
+		return 0, &SlotError{http.StatusConflict, "Lebih dari satu pasien memakai KTP tersebut"}
+	}
+	var doctorID int
+	err = tx.QueryRow(ctx, "SELECT id FROM doctors WHERE nik = $1", nik).Scan(&doctorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, &SlotError{http.StatusNotFound, "Dokter dengan NIK tersebut tidak ditemukan"}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// 3. Validasi jadwal lalu simpan di savepoint
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sp.Rollback(ctx)
+
+	if status != StatusCancelled {
+		if err := validateAppointmentSlot(ctx, sp, slotCheck{DoctorID: doctorID, PatientID: patientIDs[0], Date: date}, cfg); err != nil {
+			return 0, err
+		}
+	}
+	var id int
+	err = sp.QueryRow(ctx, `INSERT INTO appointments (patient_id, doctor_id, appointment_date, status, created_by)
+                  VALUES ($1, $2, $3, $4, $5)
+                  RETURNING id`, patientIDs[0], doctorID, date, status, createdBy).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, &SlotError{http.StatusConflict, constraintMessage(pgErr)}
+		}
+		return 0, err
+	}
+	return id, sp.Commit(ctx)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportAppointmentsWithValidAndInvalidRows(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	var ktp, nik string
+	ctx := context.Background()
+	if err := pool.QueryRow(ctx, "SELECT ktp_number FROM patients WHERE id = $1", patientID).Scan(&ktp); err != nil {
+		t.Fatalf("Gagal membaca KTP pasien test: %v", err)
+	}
+	if err := pool.QueryRow(ctx, "SELECT nik FROM doctors WHERE id = $1", doctorID).Scan(&nik); err != nil {
+		t.Fatalf("Gagal membaca NIK dokter test: %v", err)
+	}
+
+	at := func(hour int) string { return tomorrowAt(cfg, hour).Format(time.RFC3339) }
+	body := "patientKtp,doctorNik,date,status\n" +
+		fmt.Sprintf("%s,%s,%s,\n", ktp, nik, at(9)) +
+		fmt.Sprintf("%s,%s,%s,\n", randomKTP(), nik, at(10)) + // pasien tidak ada
+		fmt.Sprintf("%s,%s,besok pagi,\n", ktp, nik) + // format tanggal salah
+		fmt.Sprintf("%s,%s,%s,CONFIRMED\n", ktp, nik, at(11)) +
+		fmt.Sprintf("%s,%s,%s,\n", ktp, nik, at(20)) // di luar jam praktik
+	wantFailed := []bool{false, true, true, false, true}
+
+	handler := ImportAppointmentsHandler(pool, cfg)
+	importCSV := func(mode string) (int, ImportResponse) {
+		t.Helper()
+		rec := serve(handler, httptest.NewRequest(http.MethodPost, "/appointments/import?mode="+mode, strings.NewReader(body)))
+		return rec.Code, decodeJSON[ImportResponse](t, rec)
+	}
+	countAppointments := func() int {
+		var n int
+		pool.QueryRow(ctx, "SELECT COUNT(*) FROM appointments WHERE doctor_id = $1", doctorID).Scan(&n)
+		return n
+	}
+
+	// Mode all: satu baris gagal membatalkan semuanya
+	code, resp := importCSV("all")
+	if code != http.StatusUnprocessableEntity || resp.Inserted != 0 || resp.Failed != 3 {
+		t.Fatalf("mode all: status = %d, inserted = %d, failed = %d, want 422, 0, 3", code, resp.Inserted, resp.Failed)
+	}
+	if n := countAppointments(); n != 0 {
+		t.Fatalf("mode all: %d janji temu tersimpan, want 0", n)
+	}
+
+	// Mode partial: baris yang valid tetap disimpan
+	code, resp = importCSV("partial")
+	if code != http.StatusOK || resp.Inserted != 2 || resp.Failed != 3 {
+		t.Fatalf("mode partial: status = %d, inserted = %d, failed = %d, want 200, 2, 3", code, resp.Inserted, resp.Failed)
+	}
+	if len(resp.Rows) != len(wantFailed) {
+		t.Fatalf("jumlah hasil baris = %d, want %d", len(resp.Rows), len(wantFailed))
+	}
+	for i, row := range resp.Rows {
+		if row.Row != i+1 || (row.Error != "") != wantFailed[i] || (row.AppointmentID != 0) == wantFailed[i] {
+			t.Errorf("baris %d = %+v, want gagal %v", i+1, row, wantFailed[i])
+		}
+	}
+	if n := countAppointments(); n != 2 {
+		t.Fatalf("mode partial: %d janji temu tersimpan, want 2", n)
+	}
+}
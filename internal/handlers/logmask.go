@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// identifierMasking menentukan apakah maskIdentifier menyamarkan nilai.
+// Aktif secara default; bisa dimatikan untuk debugging lewat SetIdentifierMasking.
+var identifierMasking atomic.Bool
+
+func init() {
+	identifierMasking.Store(true)
+}
+
+// SetIdentifierMasking mengaktifkan atau menonaktifkan penyamaran identitas
+// (KTP, NIK, ID pasien/dokter) di log. Dipanggil sekali saat aplikasi dimulai.
+func SetIdentifierMasking(enabled bool) {
+	identifierMasking.Store(enabled)
+}
+
+// maskIdentifier menyamarkan identitas untuk ditulis ke log sehingga hanya
+// 4 karakter terakhir yang terlihat, mis. "3171234567890001" menjadi
+// "************0001". Nilai 4 karakter atau kurang tidak diubah.
+func maskIdentifier(v any) string {
+	s := fmt.Sprint(v)
+	if !identifierMasking.Load() || len(s) <= 4 {
+		return s
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMaskIdentifierHidesKTPInLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	t.Cleanup(func() { SetIdentifierMasking(true) })
+
+	const ktp = "3171234567890001"
+	log.Printf("Gagal menyimpan pasien %s", maskIdentifier(ktp))
+	if out := buf.String(); strings.Contains(out, ktp) || !strings.Contains(out, "************0001") {
+		t.Errorf("log = %q, want KTP disamarkan menjadi ************0001", out)
+	}
+
+	buf.Reset()
+	SetIdentifierMasking(false)
+	log.Printf("Gagal menyimpan pasien %s", maskIdentifier(ktp))
+	if out := buf.String(); !strings.Contains(out, ktp) {
+		t.Errorf("log dengan penyamaran nonaktif = %q, want KTP utuh", out)
+	}
+}
+
+func TestMaskIdentifierShortValues(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{in: 42, want: "42"},
+		{in: "1234", want: "1234"},
+		{in: 123456, want: "**3456"},
+	}
+	for _, tt := range tests {
+		if got := maskIdentifier(tt.in); got != tt.want {
+			t.Errorf("maskIdentifier(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// maintenancePath adalah endpoint untuk melihat dan mengubah mode maintenance.
+// Endpoint ini selalu dilewatkan oleh MaintenanceMiddleware agar mode
+// maintenance tetap bisa dimatikan.
+const maintenancePath = "/admin/maintenance"
+
+// MaintenanceMode menyimpan status mode maintenance yang bisa diubah saat
+// aplikasi berjalan, misalnya selama migrasi database.
+type MaintenanceMode struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewMaintenanceMode membuat MaintenanceMode dengan status awal enabled.
+// retryAfter dikirim ke klien lewat header Retry-After selama maintenance.
+func NewMaintenanceMode(enabled bool, retryAfter time.Duration) *MaintenanceMode {
+	m := &MaintenanceMode{retryAfter: retryAfter}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled melaporkan apakah mode maintenance sedang aktif.
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// MaintenanceMiddleware menolak request yang mengubah data (POST, PUT, PATCH,
+// DELETE) dengan 503 dan header Retry-After selama mode maintenance aktif.
+// Request baca (GET, HEAD, OPTIONS) tetap dilayani.
+func MaintenanceMiddleware(m *MaintenanceMode, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.Enabled() && r.URL.Path != maintenancePath {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+				w.Header().Set("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+				http.Error(w, "Sistem sedang dalam maintenance, perubahan data sementara tidak dapat dilakukan.", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MaintenanceStatus adalah body request dan response endpoint maintenance.
+type MaintenanceStatus struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// GetMaintenanceHandler mengembalikan status mode maintenance (GET /admin/maintenance).
+func GetMaintenanceHandler(m *MaintenanceMode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enabled := m.Enabled()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MaintenanceStatus{Enabled: &enabled})
+	}
+}
+
+// SetMaintenanceHandler menyalakan atau mematikan mode maintenance tanpa
+// restart (PUT /admin/maintenance, body {"enabled": true}).
+func SetMaintenanceHandler(m *MaintenanceMode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MaintenanceStatus
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if req.Enabled == nil {
+			http.Error(w, "Field enabled wajib diisi (true/false).", http.StatusUnprocessableEntity)
+			return
+		}
+
+		m.enabled.Store(*req.Enabled)
+		log.Printf("Mode maintenance diubah menjadi %t", *req.Enabled)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(req)
+	}
+}
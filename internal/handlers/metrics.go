@@ -0,0 +1,9 @@
+package handlers
+
+import "expvar"
+
+// Metrik aplikasi dipublikasikan lewat expvar dan bisa dibaca di GET /debug/vars.
+var (
+	doctorCacheHits   = expvar.NewInt("doctor_cache_hits")
+	doctorCacheMisses = expvar.NewInt("doctor_cache_misses")
+)
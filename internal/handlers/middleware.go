@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RequireJSON menolak request POST/PUT/PATCH yang Content-Type-nya bukan
+// application/json (parameter seperti charset tetap diizinkan) dengan 415.
+// Dipasang pada endpoint yang mendekode body JSON.
+func RequireJSON(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				http.Error(w, "Content-Type harus application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// CORS menambahkan header CORS untuk origin yang ada di allowedOrigins.
+// Origin request hanya dipantulkan kembali jika terdaftar; "*" di daftar
+// mengizinkan semua origin. Request preflight (OPTIONS) dijawab langsung
+// dengan 204. Jika allowedOrigins kosong, tidak ada header CORS yang dikirim.
+func CORS(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAll := slices.Contains(allowedOrigins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && len(allowedOrigins) > 0 {
+			switch {
+			case allowAll:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case slices.Contains(allowedOrigins, origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			default:
+				// Origin tidak terdaftar: tetap beri tahu cache bahwa response bergantung pada Origin
+				w.Header().Add("Vary", "Origin")
+			}
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if w.Header().Get("Access-Control-Allow-Origin") != "" {
+					w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Admin-Key, Range")
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReadOnly menolak semua request yang mengubah data (POST, PUT, PATCH, DELETE)
+// dengan 503 jika enabled, di satu tempat sehingga tidak ada handler yang
+// terlewat. Request baca tetap dilayani. Dipakai saat API berjalan di atas
+// replica database (READ_ONLY).
+func ReadOnly(enabled bool, next http.Handler) http.Handler {
+	if !enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			http.Error(w, "API sedang berjalan dalam mode baca saja, perubahan data tidak dapat dilakukan.", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// dbFreePaths adalah endpoint yang tidak memakai database sehingga tidak
+// perlu dibatasi oleh LimitDBAcquire (liveness probe harus tetap menjawab
+// walaupun pool sedang penuh).
+var dbFreePaths = []string{"/healthz", "/livez", "/version", "/debug/vars"}
+
+// busyMessage adalah pesan 503 saat database tidak tersedia tepat waktu.
+const busyMessage = "Layanan sedang sibuk, silakan coba lagi sebentar."
+
+// LimitDBAcquire memberi setiap request batas waktu timeout lewat context
+// request, yang dipakai handler untuk semua query (termasuk menunggu koneksi
+// bebas dari pool). Jika batas waktu itu habis, misalnya karena semua koneksi
+// pool sedang dipakai, response error handler diganti dengan 503 dan header
+// Retry-After alih-alih request menggantung. timeout 0 menonaktifkan batas ini.
+func LimitDBAcquire(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(dbFreePaths, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(&busyWriter{ResponseWriter: w, ctx: ctx, timeout: timeout}, r.WithContext(ctx))
+	})
+}
+
+// busyWriter mengganti response 5xx dengan 503 jika batas waktu request dari
+// LimitDBAcquire sudah habis, sehingga klien tahu request boleh diulang.
+type busyWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	timeout time.Duration
+	busy    bool
+}
+
+func (w *busyWriter) WriteHeader(status int) {
+	if status < http.StatusInternalServerError || !errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	log.Printf("Database tidak tersedia dalam %s, request dijawab 503", w.timeout)
+	w.busy = true
+	h := w.ResponseWriter.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Retry-After", strconv.Itoa(int(w.timeout.Seconds())+1))
+	w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintln(w.ResponseWriter, busyMessage)
+}
+
+func (w *busyWriter) Write(b []byte) (int, error) {
+	if w.busy {
+		// Body error asli dari handler dibuang, pesan 503 sudah ditulis
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// statusRecorder mencatat kode status yang ditulis handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// LogRequests mencatat setiap request (method, path, status, durasi) ke log.
+// Response error (status 4xx/5xx) selalu dicatat, sedangkan response sukses
+// hanya dicatat 1 dari setiap sampleRate request agar log tidak terlalu ramai.
+// sampleRate 1 (atau kurang) berarti semua request dicatat.
+func LogRequests(sampleRate int, next http.Handler) http.Handler {
+	var successCount atomic.Uint64
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status < 400 && sampleRate > 1 && successCount.Add(1)%uint64(sampleRate) != 1 {
+			return
+		}
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestLimitDBAcquireReturns503WhenPoolIsExhausted(t *testing.T) {
+	pool := testPool(t, func(c *pgxpool.Config) { c.MaxConns = 1 })
+
+	// Tahan satu-satunya koneksi pool selama test
+	conn, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Gagal mengambil koneksi: %v", err)
+	}
+	defer conn.Release()
+
+	handler := LimitDBAcquire(100*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var one int
+		if err := pool.QueryRow(r.Context(), "SELECT 1").Scan(&one); err != nil {
+			http.Error(w, "Gagal mengambil data", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/doctors", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("header Retry-After tidak dikirim")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("503 baru dikirim setelah %s", elapsed)
+	}
+}
+
+func TestLimitDBAcquireRewritesErrorAfterDeadline(t *testing.T) {
+	handler := LimitDBAcquire(20*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		http.Error(w, "Gagal mengambil data", http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/doctors", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if got := rec.Body.String(); got != busyMessage+"\n" {
+		t.Errorf("body = %q, want %q", got, busyMessage+"\n")
+	}
+}
+
+func TestLimitDBAcquireKeepsErrorsBeforeDeadline(t *testing.T) {
+	handler := LimitDBAcquire(time.Second, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Gagal mengambil data", http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/doctors", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+}
+
+// okHandler selalu menjawab 200.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		origin      string
+		wantAllowed string
+	}{
+		{name: "origin terdaftar", allowed: []string{"https://admin.klinik.id"}, origin: "https://admin.klinik.id", wantAllowed: "https://admin.klinik.id"},
+		{name: "origin tidak terdaftar", allowed: []string{"https://admin.klinik.id"}, origin: "https://evil.example", wantAllowed: ""},
+		{name: "wildcard", allowed: []string{"*"}, origin: "https://mana.saja", wantAllowed: "*"},
+		{name: "CORS nonaktif", allowed: nil, origin: "https://admin.klinik.id", wantAllowed: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/doctors", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			CORS(tt.allowed, okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowed {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := CORS([]string{"https://admin.klinik.id"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("preflight tidak seharusnya diteruskan ke handler")
+	}))
+
+	for _, origin := range []string{"https://admin.klinik.id", "https://evil.example"} {
+		req := httptest.NewRequest(http.MethodOptions, "/patients", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("%s: status = %d, want 204", origin, rec.Code)
+		}
+		allowed := origin == "https://admin.klinik.id"
+		if got := rec.Header().Get("Access-Control-Allow-Methods") != ""; got != allowed {
+			t.Errorf("%s: Access-Control-Allow-Methods dikirim = %v, want %v", origin, got, allowed)
+		}
+	}
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	m := NewMaintenanceMode(true, 2*time.Minute)
+	handler := MaintenanceMiddleware(m, okHandler)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/patients", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("POST saat maintenance: status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("Retry-After = %q, want %q", got, "120")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/patients", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET saat maintenance: status = %d, want 200", rec.Code)
+	}
+
+	// Endpoint maintenance tetap bisa dipakai untuk mematikan mode maintenance
+	set := MaintenanceMiddleware(m, SetMaintenanceHandler(m))
+	rec = httptest.NewRecorder()
+	set.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, maintenancePath, strings.NewReader(`{"enabled": false}`)))
+	if rec.Code != http.StatusOK || m.Enabled() {
+		t.Fatalf("matikan maintenance: status = %d, enabled = %v, want 200 dan false", rec.Code, m.Enabled())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/patients", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST setelah maintenance dimatikan: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		method  string
+		want    int
+	}{
+		{name: "baca saja, POST", enabled: true, method: http.MethodPost, want: http.StatusServiceUnavailable},
+		{name: "baca saja, DELETE", enabled: true, method: http.MethodDelete, want: http.StatusServiceUnavailable},
+		{name: "baca saja, GET", enabled: true, method: http.MethodGet, want: http.StatusOK},
+		{name: "nonaktif, POST", enabled: false, method: http.MethodPost, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ReadOnly(tt.enabled, okHandler).ServeHTTP(rec, httptest.NewRequest(tt.method, "/patients", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// captureLog mengalihkan output package log selama test dan mengembalikan
+// fungsi untuk menghitung baris yang sudah ditulis.
+func captureLog(t *testing.T) func() int {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return func() int { return strings.Count(buf.String(), "\n") }
+}
+
+func TestLogRequestsSamplesSuccesses(t *testing.T) {
+	lines := captureLog(t)
+	handler := LogRequests(5, okHandler)
+	for range 20 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/doctors", nil))
+	}
+	if got := lines(); got != 4 {
+		t.Fatalf("%d request sukses dicatat, want 4 (1 dari 5)", got)
+	}
+}
+
+func TestLogRequestsAlwaysLogsErrors(t *testing.T) {
+	lines := captureLog(t)
+	handler := LogRequests(5, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gagal", http.StatusInternalServerError)
+	}))
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/doctors", nil))
+	}
+	if got := lines(); got != 3 {
+		t.Fatalf("%d request gagal dicatat, want 3", got)
+	}
+}
+
+func TestLogRequestsWithoutSamplingLogsEverything(t *testing.T) {
+	lines := captureLog(t)
+	handler := LogRequests(1, okHandler)
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/doctors", nil))
+	}
+	if got := lines(); got != 3 {
+		t.Fatalf("%d request dicatat, want 3", got)
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	// Body tidak valid agar CreatePatientHandler berhenti di validasi (422)
+	// sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := RequireJSON(CreatePatientHandler(nil, testConfig(), nil))
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{contentType: "text/plain", want: http.StatusUnsupportedMediaType},
+		{contentType: "", want: http.StatusUnsupportedMediaType},
+		{contentType: "application/json", want: http.StatusUnprocessableEntity},
+		{contentType: "application/json; charset=utf-8", want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/patients", strings.NewReader(`{"ktpNumber": "123"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if rec := serve(handler, req); rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+)
+
+// dateLayout adalah format tanggal (YYYY-MM-DD) yang dipakai di query string.
+const dateLayout = "2006-01-02"
+
+// DateRange adalah rentang tanggal opsional hasil parsing query string.
+// From atau To bernilai nil jika parameternya tidak dikirim.
+type DateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// parseDateRange membaca dua parameter tanggal (YYYY-MM-DD) dari query string
+// sebagai tengah malam di zona waktu loc, dan memastikan tanggal awal tidak
+// melewati tanggal akhir.
+// Pesan error yang dikembalikan siap dikirim sebagai response 400.
+func parseDateRange(r *http.Request, fromKey, toKey string, loc *time.Location) (DateRange, error) {
+	var dr DateRange
+	if v := r.URL.Query().Get(fromKey); v != "" {
+		from, err := time.ParseInLocation(dateLayout, v, loc)
+		if err != nil {
+			return dr, fmt.Errorf("Format %s harus YYYY-MM-DD", fromKey)
+		}
+		dr.From = &from
+	}
+	if v := r.URL.Query().Get(toKey); v != "" {
+		to, err := time.ParseInLocation(dateLayout, v, loc)
+		if err != nil {
+			return dr, fmt.Errorf("Format %s harus YYYY-MM-DD", toKey)
+		}
+		dr.To = &to
+	}
+	if dr.From != nil && dr.To != nil && dr.From.After(*dr.To) {
+		return dr, fmt.Errorf("%s tidak boleh setelah %s", fromKey, toKey)
+	}
+	return dr, nil
+}
+
+// parseWeekday mengurai nilai hari (1 = Senin ... 7 = Minggu, sama seperti
+// doctor_schedules.day_of_week) dari parameter bernama key. Nilai kosong, bukan
+// angka, atau di luar 1-7 ditolak agar tidak terbaca sebagai "tidak ada jadwal".
+// Pesan error yang dikembalikan siap dikirim sebagai response 400.
+func parseWeekday(value, key string) (int, error) {
+	if value == "" {
+		return 0, fmt.Errorf("Parameter %s wajib diisi (1 = Senin ... 7 = Minggu).", key)
+	}
+	day, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s harus berupa angka 1 (Senin) sampai 7 (Minggu), bukan %q.", key, value)
+	}
+	if day < 1 || day > 7 {
+		return 0, fmt.Errorf("%s harus antara 1 (Senin) dan 7 (Minggu).", key)
+	}
+	return day, nil
+}
+
+// parsePagination membaca ?limit= dan ?offset= dari query string.
+// limit default-nya cfg.DefaultPageSize dan dibatasi maksimal cfg.MaxPageSize.
+// Jika keduanya tidak ada, header Range (mis. "Range: items=0-19") dipakai
+// sebagai gantinya untuk klien admin yang memakai gaya tersebut.
+// Pesan error yang dikembalikan siap dikirim sebagai response 400.
+func parsePagination(r *http.Request, cfg *config.Config) (limit, offset int, err error) {
+	if rng, ok := rangeHeader(r); ok {
+		return parseRangePagination(rng, cfg)
+	}
+
+	limit = cfg.DefaultPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("limit harus berupa angka lebih dari 0")
+		}
+	}
+	if limit > cfg.MaxPageSize {
+		limit = cfg.MaxPageSize
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset harus berupa angka 0 atau lebih")
+		}
+	}
+	return limit, offset, nil
+}
+
+// rangeHeader mengembalikan header Range jika request memakainya untuk
+// pagination, yaitu jika ?limit= dan ?offset= tidak dikirim.
+func rangeHeader(r *http.Request) (string, bool) {
+	q := r.URL.Query()
+	rng := r.Header.Get("Range")
+	return rng, rng != "" && !q.Has("limit") && !q.Has("offset")
+}
+
+// parseRangePagination mengurai header "Range: items=<awal>-<akhir>" (inklusif)
+// menjadi limit dan offset, dengan batas cfg.MaxPageSize yang sama.
+func parseRangePagination(rng string, cfg *config.Config) (limit, offset int, err error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(rng), "items=")
+	first, last, ok2 := strings.Cut(spec, "-")
+	if !ok || !ok2 {
+		return 0, 0, errors.New("header Range harus berformat items=<awal>-<akhir>, mis. items=0-19")
+	}
+	offset, err1 := strconv.Atoi(first)
+	end, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || offset < 0 || end < offset {
+		return 0, 0, errors.New("header Range harus berformat items=<awal>-<akhir>, mis. items=0-19")
+	}
+	return min(end-offset+1, cfg.MaxPageSize), offset, nil
+}
+
+// paginate memotong slice yang sudah ada di memori sesuai limit dan offset.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	end := min(offset+limit, len(items))
+	return items[offset:end]
+}
+
+// setPaginationHeaders menambahkan header X-Total-Count dan Link (RFC 5988,
+// rel first/prev/next/last) untuk endpoint daftar yang memakai parsePagination.
+// URL di Link mempertahankan query string request dan hanya mengganti limit/offset.
+// Jika request memakai header Range, Content-Range ("items 0-19/57") juga dikirim.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, total, limit, offset int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if _, ok := rangeHeader(r); ok {
+		if offset < total {
+			w.Header().Set("Content-Range", fmt.Sprintf("items %d-%d/%d", offset, min(offset+limit, total)-1, total))
+		} else {
+			w.Header().Set("Content-Range", fmt.Sprintf("items */%d", total))
+		}
+	}
+
+	pageURL := func(off int) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(off))
+		u.RawQuery = q.Encode()
+		return u.RequestURI()
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(0))}
+	if offset > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(max(offset-limit, 0))))
+	}
+	if offset+limit < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(offset+limit)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(last)))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
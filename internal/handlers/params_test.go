@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePagination(t *testing.T) {
+	cfg := testConfig() // DefaultPageSize 20, MaxPageSize 100
+	tests := []struct {
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantErr    bool
+	}{
+		{query: "", wantLimit: 20, wantOffset: 0},
+		{query: "limit=5&offset=10", wantLimit: 5, wantOffset: 10},
+		{query: "limit=500", wantLimit: 100, wantOffset: 0},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=-1", wantErr: true},
+		{query: "limit=abc", wantErr: true},
+		{query: "offset=-1", wantErr: true},
+		{query: "offset=abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/patients?"+tt.query, nil)
+			limit, offset, err := parsePagination(r, cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePagination(%q) = %d, %d; want error", tt.query, limit, offset)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePagination(%q): %v", tt.query, err)
+			}
+			if limit != tt.wantLimit || offset != tt.wantOffset {
+				t.Fatalf("parsePagination(%q) = %d, %d; want %d, %d", tt.query, limit, offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParsePaginationRangeMatchesQuery(t *testing.T) {
+	cfg := testConfig()
+
+	byQuery := httptest.NewRequest(http.MethodGet, "/patients?limit=10&offset=20", nil)
+	byRange := httptest.NewRequest(http.MethodGet, "/patients", nil)
+	byRange.Header.Set("Range", "items=20-29")
+
+	qLimit, qOffset, err := parsePagination(byQuery, cfg)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	rLimit, rOffset, err := parsePagination(byRange, cfg)
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if qLimit != rLimit || qOffset != rOffset {
+		t.Fatalf("Range = %d, %d; query = %d, %d", rLimit, rOffset, qLimit, qOffset)
+	}
+}
+
+func TestParsePaginationRange(t *testing.T) {
+	cfg := testConfig()
+	tests := []struct {
+		rng        string
+		wantLimit  int
+		wantOffset int
+		wantErr    bool
+	}{
+		{rng: "items=0-19", wantLimit: 20, wantOffset: 0},
+		{rng: "items=0-999", wantLimit: 100, wantOffset: 0},
+		{rng: "items=5-5", wantLimit: 1, wantOffset: 5},
+		{rng: "items=10-5", wantErr: true},
+		{rng: "items=-1-5", wantErr: true},
+		{rng: "bytes=0-19", wantErr: true},
+		{rng: "items=abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rng, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/patients", nil)
+			r.Header.Set("Range", tt.rng)
+			limit, offset, err := parsePagination(r, cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Range %q = %d, %d; want error", tt.rng, limit, offset)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Range %q: %v", tt.rng, err)
+			}
+			if limit != tt.wantLimit || offset != tt.wantOffset {
+				t.Fatalf("Range %q = %d, %d; want %d, %d", tt.rng, limit, offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParsePaginationQueryOverridesRange(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/patients?limit=5", nil)
+	r.Header.Set("Range", "items=20-29")
+	limit, offset, err := parsePagination(r, testConfig())
+	if err != nil || limit != 5 || offset != 0 {
+		t.Fatalf("parsePagination = %d, %d, %v; want 5, 0 dari query", limit, offset, err)
+	}
+}
+
+func TestSetPaginationHeadersContentRange(t *testing.T) {
+	tests := []struct {
+		rng    string
+		limit  int
+		offset int
+		want   string
+	}{
+		{rng: "items=0-19", limit: 20, offset: 0, want: "items 0-19/57"},
+		{rng: "items=40-59", limit: 20, offset: 40, want: "items 40-56/57"},
+		{rng: "items=60-79", limit: 20, offset: 60, want: "items */57"},
+		{rng: "", limit: 20, offset: 0, want: ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/patients", nil)
+		if tt.rng != "" {
+			r.Header.Set("Range", tt.rng)
+		}
+		rec := httptest.NewRecorder()
+		setPaginationHeaders(rec, r, 57, tt.limit, tt.offset)
+		if got := rec.Header().Get("Content-Range"); got != tt.want {
+			t.Errorf("Range %q: Content-Range = %q, want %q", tt.rng, got, tt.want)
+		}
+	}
+}
+
+func TestSetPaginationHeadersMiddlePage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/patients?sort=name&limit=20&offset=40", nil)
+	rec := httptest.NewRecorder()
+	setPaginationHeaders(rec, r, 95, 20, 40)
+
+	if got := rec.Header().Get("X-Total-Count"); got != "95" {
+		t.Errorf("X-Total-Count = %q, want 95", got)
+	}
+	want := `</patients?limit=20&offset=0&sort=name>; rel="first", ` +
+		`</patients?limit=20&offset=20&sort=name>; rel="prev", ` +
+		`</patients?limit=20&offset=60&sort=name>; rel="next", ` +
+		`</patients?limit=20&offset=80&sort=name>; rel="last"`
+	if got := rec.Header().Get("Link"); got != want {
+		t.Errorf("Link = %s\nwant %s", got, want)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for _, value := range []string{"1", "4", "7"} {
+		if _, err := parseWeekday(value, "day"); err != nil {
+			t.Errorf("parseWeekday(%q): %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "0", "8", "-1", "senin", "1.5"} {
+		if day, err := parseWeekday(value, "day"); err == nil {
+			t.Errorf("parseWeekday(%q) = %d, want error", value, day)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// profileAppointmentLimit adalah jumlah maksimum janji temu di setiap bagian
+// (upcoming dan recent) pada profil pasien.
+const profileAppointmentLimit = 5
+
+// PatientProfileResponse adalah data pasien beserta ringkasan janji temunya.
+type PatientProfileResponse struct {
+	Patient
+	// Upcoming berisi janji temu aktif yang belum dimulai, dari yang terdekat.
+	Upcoming []AppointmentResponse `json:"upcoming"`
+	// Recent berisi janji temu yang sudah lewat (semua status), dari yang terbaru.
+	Recent []AppointmentResponse `json:"recent"`
+}
+
+// GetPatientProfileHandler mengembalikan data pasien beserta janji temu
+// mendatang dan terakhirnya dalam satu response (GET /patients/{id}/profile),
+// masing-masing maksimal profileAppointmentLimit. Seperti GET /patients/{id},
+// pasien nonaktif tetap ditampilkan dengan "active": false.
+func GetPatientProfileHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID pasien
+		patientID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || patientID <= 0 {
+			http.Error(w, "ID pasien tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil data pasien
+		var resp PatientProfileResponse
+		err = withRetry(r.Context(), func() error {
+			return scanPatient(dbpool.QueryRow(r.Context(), "SELECT "+patientColumns+" FROM patients WHERE id = $1", patientID), &resp.Patient)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Pasien tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Ambil janji temu mendatang dan terakhir
+		upcomingQuery := `SELECT a.id, a.doctor_id, d.name, a.appointment_date, a.status, a.checked_in_at
+                  FROM appointments a
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.patient_id = $1 AND a.appointment_date >= NOW() AND ` + activeAppointmentCondition + `
+                  ORDER BY a.appointment_date, a.id
+                  LIMIT $2`
+		recentQuery := `SELECT a.id, a.doctor_id, d.name, a.appointment_date, a.status, a.checked_in_at
+                  FROM appointments a
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.patient_id = $1 AND a.appointment_date < NOW()
+                  ORDER BY a.appointment_date DESC, a.id DESC
+                  LIMIT $2`
+
+		err = withRetry(r.Context(), func() error {
+			var err error
+			if resp.Upcoming, err = queryPatientAppointments(r.Context(), dbpool, upcomingQuery, patientID); err != nil {
+				return err
+			}
+			resp.Recent, err = queryPatientAppointments(r.Context(), dbpool, recentQuery, patientID)
+			return err
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// queryPatientAppointments menjalankan query janji temu profil pasien
+// ($1 = ID pasien, $2 = batas jumlah) dan memindai hasilnya.
+func queryPatientAppointments(ctx context.Context, dbpool *pgxpool.Pool, query string, patientID int) ([]AppointmentResponse, error) {
+	rows, err := dbpool.Query(ctx, query, patientID, profileAppointmentLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appointments := []AppointmentResponse{}
+	for rows.Next() {
+		var appt AppointmentResponse
+		if err := rows.Scan(&appt.ID, &appt.DoctorID, &appt.DoctorName, &appt.AppointmentDate, &appt.Status, &appt.CheckedInAt); err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, appt)
+	}
+	return appointments, rows.Err()
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestGetPatientProfileHasBothSections(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	// Recent memuat semua status, termasuk yang dibatalkan
+	past := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9).AddDate(0, 0, -2), StatusCheckedIn)
+	pastCancelled := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9).AddDate(0, 0, -3), StatusCancelled)
+	soon := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	later := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusConfirmed)
+	// Janji temu mendatang yang dibatalkan tidak termasuk upcoming
+	insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 13), StatusCancelled)
+
+	rec := serveJSON(t, routed("GET /patients/{id}/profile", GetPatientProfileHandler(pool)), http.MethodGet, fmt.Sprintf("/patients/%d/profile", patientID), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	profile := decodeJSON[PatientProfileResponse](t, rec)
+	if profile.ID != patientID || profile.FullName == "" {
+		t.Errorf("pasien = %+v, want data pasien %d", profile.Patient, patientID)
+	}
+	ids := func(appointments []AppointmentResponse) []int {
+		out := []int{}
+		for _, a := range appointments {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+	if got, want := ids(profile.Upcoming), []int{soon, later}; !slices.Equal(got, want) {
+		t.Errorf("upcoming = %v, want %v", got, want)
+	}
+	if got, want := ids(profile.Recent), []int{past, pastCancelled}; !slices.Equal(got, want) {
+		t.Errorf("recent = %v, want %v", got, want)
+	}
+	if len(profile.Upcoming) > 0 && profile.Upcoming[0].DoctorName == "" {
+		t.Errorf("upcoming[0].doctorName kosong, want nama dokter")
+	}
+}
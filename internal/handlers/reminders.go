@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReminderResponse adalah janji temu yang perlu dikirimi pengingat,
+// beserta nama pasien dan dokter untuk isi pesannya.
+type ReminderResponse struct {
+	Appointment
+	PatientName string `json:"patientName"`
+	DoctorName  string `json:"doctorName"`
+}
+
+// GetPendingRemindersHandler mengembalikan janji temu terjadwal yang belum
+// dikirimi pengingat dan akan dimulai dalam jendela pengingat dokternya
+// (reminder_lead_time, atau cfg.ReminderLeadTime jika dokter tidak mengaturnya).
+// ?within= (durasi) memakai satu jendela yang sama untuk semua dokter.
+func GetPendingRemindersHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Baca jendela waktu (nil = per dokter)
+		var within *time.Duration
+		if v := r.URL.Query().Get("within"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				http.Error(w, "within harus berupa durasi positif, mis. 24h atau 90m", http.StatusBadRequest)
+				return
+			}
+			within = &d
+		}
+
+		// 2. Ambil janji temu yang belum dikirimi pengingat
+		query := `SELECT ` + qualifiedAppointmentColumns("a") + `, p.full_name, d.name
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.reminder_sent_at IS NULL
+                  AND a.status IN ($1, $2)
+                  AND a.appointment_date > NOW()
+                  AND a.appointment_date <= NOW() + COALESCE($3::interval, d.reminder_lead_time, $4::interval)
+                  ORDER BY a.appointment_date`
+
+		var reminders []ReminderResponse
+		err := withRetry(r.Context(), func() error {
+			reminders = nil
+			rows, err := dbpool.Query(r.Context(), query, StatusConfirmed, StatusRescheduled, within, cfg.ReminderLeadTime)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var rem ReminderResponse
+				if err := scanAppointment(rows, &rem.Appointment, &rem.PatientName, &rem.DoctorName); err != nil {
+					return err
+				}
+				reminders = append(reminders, rem)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data pengingat", http.StatusInternalServerError)
+			return
+		}
+
+		if reminders == nil {
+			reminders = []ReminderResponse{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reminders)
+	}
+}
+
+// UnconfirmedAppointmentResponse adalah janji temu yang belum dikonfirmasi
+// pasien, beserta nama pasien dan dokter untuk ditindaklanjuti staf.
+type UnconfirmedAppointmentResponse struct {
+	Appointment
+	PatientName string `json:"patientName"`
+	DoctorName  string `json:"doctorName"`
+}
+
+// GetUnconfirmedAppointmentsHandler mengembalikan janji temu terjadwal yang
+// akan dimulai dalam ?within= (durasi, default cfg.ConfirmationWindow) tetapi
+// belum dikonfirmasi pasien (confirmed_at kosong), dari yang terdekat. Janji
+// temu di daftar ini sudah melewati batas konfirmasi dan perlu ditindaklanjuti.
+func GetUnconfirmedAppointmentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Baca jendela waktu
+		within := cfg.ConfirmationWindow
+		if v := r.URL.Query().Get("within"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				http.Error(w, "within harus berupa durasi positif, mis. 24h atau 90m", http.StatusBadRequest)
+				return
+			}
+			within = d
+		}
+
+		// 2. Ambil janji temu yang belum dikonfirmasi
+		query := `SELECT ` + qualifiedAppointmentColumns("a") + `, p.full_name, d.name
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.confirmed_at IS NULL
+                  AND a.status IN ($1, $2)
+                  AND a.appointment_date > NOW()
+                  AND a.appointment_date <= NOW() + $3::interval
+                  ORDER BY a.appointment_date`
+
+		var appointments []UnconfirmedAppointmentResponse
+		err := withRetry(r.Context(), func() error {
+			appointments = nil
+			rows, err := dbpool.Query(r.Context(), query, StatusConfirmed, StatusRescheduled, within)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var appt UnconfirmedAppointmentResponse
+				if err := scanAppointment(rows, &appt.Appointment, &appt.PatientName, &appt.DoctorName); err != nil {
+					return err
+				}
+				appointments = append(appointments, appt)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		if appointments == nil {
+			appointments = []UnconfirmedAppointmentResponse{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appointments)
+	}
+}
+
+// MarkReminderSentHandler menandai pengingat janji temu sudah dikirim.
+// Jika sudah pernah ditandai, waktu pengiriman pertama dipertahankan.
+func MarkReminderSentHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appointmentID := r.PathValue("id")
+
+		query := `UPDATE appointments SET reminder_sent_at = COALESCE(reminder_sent_at, NOW())
+                  WHERE id = $1
+                  RETURNING ` + appointmentColumns
+
+		var appt Appointment
+		err := scanAppointment(dbpool.QueryRow(r.Context(), query, appointmentID), &appt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			log.Printf("Gagal menandai pengingat terkirim: %v", err)
+			http.Error(w, "Gagal menyimpan status pengingat", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(appt)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+const reminderSentRoute = "POST /appointments/{id}/reminder-sent"
+
+// pendingReminderIDs mengembalikan ID janji temu dari GET /appointments/reminders/pending.
+func pendingReminderIDs(t *testing.T, handler http.Handler, target string) []int {
+	t.Helper()
+	rec := serveJSON(t, handler, http.MethodGet, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d, want 200 (%s)", target, rec.Code, rec.Body)
+	}
+	var ids []int
+	for _, rem := range decodeJSON[[]ReminderResponse](t, rec) {
+		ids = append(ids, rem.ID)
+	}
+	return ids
+}
+
+func TestPendingRemindersAndMarkSent(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	soon := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(2*time.Hour).Truncate(time.Minute), StatusConfirmed)
+	later := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(72*time.Hour).Truncate(time.Minute), StatusConfirmed)
+	pending := GetPendingRemindersHandler(pool, cfg)
+
+	ids := pendingReminderIDs(t, pending, "/appointments/reminders/pending")
+	if !slices.Contains(ids, soon) {
+		t.Errorf("janji temu 2 jam lagi (%d) tidak ada di pengingat: %v", soon, ids)
+	}
+	if slices.Contains(ids, later) {
+		t.Errorf("janji temu 3 hari lagi (%d) seharusnya di luar jendela 24 jam: %v", later, ids)
+	}
+
+	mark := routed(reminderSentRoute, MarkReminderSentHandler(pool))
+	target := fmt.Sprintf("/appointments/%d/reminder-sent", soon)
+	rec := serveJSON(t, mark, http.MethodPost, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("tandai terkirim: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	first := decodeJSON[Appointment](t, rec)
+	if first.ReminderSentAt == nil {
+		t.Fatal("reminderSentAt kosong setelah ditandai terkirim")
+	}
+	if ids := pendingReminderIDs(t, pending, "/appointments/reminders/pending"); slices.Contains(ids, soon) {
+		t.Errorf("janji temu %d masih ada di pengingat setelah ditandai terkirim", soon)
+	}
+
+	// Menandai ulang mempertahankan waktu pengiriman pertama.
+	rec = serveJSON(t, mark, http.MethodPost, target, nil)
+	if again := decodeJSON[Appointment](t, rec); again.ReminderSentAt == nil || !again.ReminderSentAt.Equal(first.ReminderSentAt.Time) {
+		t.Errorf("reminderSentAt berubah setelah ditandai ulang: %v, want %v", again.ReminderSentAt, first.ReminderSentAt)
+	}
+
+	rec = serveJSON(t, mark, http.MethodPost, "/appointments/0/reminder-sent", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("janji temu yang tidak ada: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPendingRemindersUseDoctorLeadTime(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	early := createTestDoctor(t, pool)
+	late := createTestDoctor(t, pool)
+	execSQL(t, pool, "UPDATE doctors SET reminder_lead_time = INTERVAL '48 hours' WHERE id = $1", early)
+	execSQL(t, pool, "UPDATE doctors SET reminder_lead_time = INTERVAL '2 hours' WHERE id = $1", late)
+	patientID := createTestPatient(t, pool)
+	at := func(d time.Duration) time.Time { return time.Now().Add(d).Truncate(time.Minute) }
+
+	// Janji temu 30 jam lagi sudah masuk jendela 48 jam, tetapi belum masuk jendela 2 jam.
+	earlyAppt := insertTestAppointment(t, pool, patientID, early, at(30*time.Hour), StatusConfirmed)
+	lateAppt := insertTestAppointment(t, pool, patientID, late, at(31*time.Hour), StatusConfirmed)
+	lateSoon := insertTestAppointment(t, pool, patientID, late, at(time.Hour), StatusConfirmed)
+
+	ids := pendingReminderIDs(t, GetPendingRemindersHandler(pool, cfg), "/appointments/reminders/pending")
+	if !slices.Contains(ids, earlyAppt) {
+		t.Errorf("janji temu 30 jam lagi pada dokter 48 jam (%d) tidak ada di pengingat: %v", earlyAppt, ids)
+	}
+	if slices.Contains(ids, lateAppt) {
+		t.Errorf("janji temu 31 jam lagi pada dokter 2 jam (%d) seharusnya belum masuk pengingat: %v", lateAppt, ids)
+	}
+	if !slices.Contains(ids, lateSoon) {
+		t.Errorf("janji temu 1 jam lagi pada dokter 2 jam (%d) tidak ada di pengingat: %v", lateSoon, ids)
+	}
+}
+
+const attendanceRoute = "POST /appointments/{id}/attendance"
+
+// unconfirmedIDs mengembalikan ID janji temu dari GET /appointments/unconfirmed.
+func unconfirmedIDs(t *testing.T, handler http.Handler, target string) []int {
+	t.Helper()
+	rec := serveJSON(t, handler, http.MethodGet, target, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d, want 200 (%s)", target, rec.Code, rec.Body)
+	}
+	var ids []int
+	for _, appt := range decodeJSON[[]UnconfirmedAppointmentResponse](t, rec) {
+		ids = append(ids, appt.ID)
+	}
+	return ids
+}
+
+func TestUnconfirmedAppointmentsAndConfirmAttendance(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	soon := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(2*time.Hour).Truncate(time.Minute), StatusConfirmed)
+	later := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(48*time.Hour).Truncate(time.Minute), StatusConfirmed)
+	past := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(-2*time.Hour).Truncate(time.Minute), StatusConfirmed)
+	unconfirmed := GetUnconfirmedAppointmentsHandler(pool, cfg)
+
+	ids := unconfirmedIDs(t, unconfirmed, "/appointments/unconfirmed?within=24h")
+	if !slices.Contains(ids, soon) {
+		t.Errorf("janji temu 2 jam lagi (%d) tidak ada di daftar belum dikonfirmasi: %v", soon, ids)
+	}
+	if slices.Contains(ids, later) || slices.Contains(ids, past) {
+		t.Errorf("daftar belum dikonfirmasi = %v, want tanpa %d (di luar jendela) dan %d (sudah lewat)", ids, later, past)
+	}
+
+	confirm := routed(attendanceRoute, ConfirmAttendanceHandler(pool))
+	rec := serveJSON(t, confirm, http.MethodPost, fmt.Sprintf("/appointments/%d/attendance", soon), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("konfirmasi: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	first := decodeJSON[Appointment](t, rec)
+	if first.ConfirmedAt == nil {
+		t.Fatal("confirmedAt kosong setelah dikonfirmasi")
+	}
+	if ids := unconfirmedIDs(t, unconfirmed, "/appointments/unconfirmed?within=24h"); slices.Contains(ids, soon) {
+		t.Errorf("janji temu %d masih belum dikonfirmasi setelah konfirmasi", soon)
+	}
+
+	// Konfirmasi ulang mempertahankan waktu konfirmasi pertama
+	rec = serveJSON(t, confirm, http.MethodPost, fmt.Sprintf("/appointments/%d/attendance", soon), nil)
+	if again := decodeJSON[Appointment](t, rec); again.ConfirmedAt == nil || !again.ConfirmedAt.Equal(first.ConfirmedAt.Time) {
+		t.Errorf("confirmedAt berubah setelah konfirmasi ulang: %v, want %v", again.ConfirmedAt, first.ConfirmedAt)
+	}
+
+	rec = serveJSON(t, confirm, http.MethodPost, fmt.Sprintf("/appointments/%d/attendance", past), nil)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("konfirmasi janji temu yang sudah lewat: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+}
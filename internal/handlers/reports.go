@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DoctorAppointmentReport adalah rekap janji temu satu dokter dalam suatu periode.
+type DoctorAppointmentReport struct {
+	DoctorID   int    `json:"doctorId"`
+	DoctorName string `json:"doctorName"`
+	Specialty  string `json:"specialty"`
+	// Total adalah semua janji temu pada periode, kecuali slot yang masih di-hold.
+	Total int `json:"total"`
+	// Completed adalah janji temu yang pasiennya sudah check-in.
+	Completed int `json:"completed"`
+	Cancelled int `json:"cancelled"`
+	// NoShow adalah janji temu yang lewat tanpa check-in (NEEDS_RESOLUTION).
+	NoShow int `json:"noShow"`
+}
+
+// GetDoctorAppointmentReportHandler merekap janji temu per dokter
+// (GET /reports/doctor-appointments?from=YYYY-MM-DD&to=YYYY-MM-DD), diurutkan
+// dari total terbanyak. Dokter tanpa janji temu di periode tersebut tetap
+// ditampilkan dengan angka 0. from dan to opsional dan inklusif.
+func GetDoctorAppointmentReportHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi rentang tanggal
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var from, to *time.Time
+		if dr.From != nil {
+			from = dr.From
+		}
+		if dr.To != nil {
+			end := dr.To.AddDate(0, 0, 1)
+			to = &end
+		}
+
+		// 2. Rekap per dokter dengan satu query GROUP BY
+		query := `SELECT d.id, d.name, d.specialty,
+                         COUNT(a.id),
+                         COUNT(a.id) FILTER (WHERE a.status = $3),
+                         COUNT(a.id) FILTER (WHERE a.status = $4),
+                         COUNT(a.id) FILTER (WHERE a.status = $5)
+                  FROM doctors d
+                  LEFT JOIN appointments a ON a.doctor_id = d.id
+                       AND a.status <> $6
+                       AND ($1::timestamptz IS NULL OR a.appointment_date >= $1)
+                       AND ($2::timestamptz IS NULL OR a.appointment_date < $2)
+                  GROUP BY d.id, d.name, d.specialty
+                  ORDER BY COUNT(a.id) DESC, d.name, d.id`
+
+		var reports []DoctorAppointmentReport
+		err = withRetry(r.Context(), func() error {
+			reports = []DoctorAppointmentReport{}
+			rows, err := dbpool.Query(r.Context(), query, from, to,
+				StatusCheckedIn, StatusCancelled, StatusNeedsResolution, StatusHeld)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var rep DoctorAppointmentReport
+				if err := rows.Scan(&rep.DoctorID, &rep.DoctorName, &rep.Specialty,
+					&rep.Total, &rep.Completed, &rep.Cancelled, &rep.NoShow); err != nil {
+					return err
+				}
+				reports = append(reports, rep)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal membuat laporan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reports)
+	}
+}
+
+// HourCount adalah jumlah janji temu yang dimulai pada satu jam dalam sehari.
+type HourCount struct {
+	Hour  int `json:"hour"`
+	Count int `json:"count"`
+}
+
+// GetPeakHoursReportHandler merekap jumlah janji temu per jam (0-23) pada
+// rentang ?from= sampai ?to= (GET /reports/peak-hours, keduanya opsional dan
+// inklusif), menurut zona waktu aplikasi. Janji temu yang dibatalkan dan slot
+// yang masih di-hold tidak dihitung. Semua 24 jam selalu dikembalikan, jam
+// tanpa janji temu bernilai 0.
+func GetPeakHoursReportHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi rentang tanggal
+		dr, err := parseDateRange(r, "from", "to", cfg.Location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var from, to *time.Time
+		if dr.From != nil {
+			from = dr.From
+		}
+		if dr.To != nil {
+			end := dr.To.AddDate(0, 0, 1)
+			to = &end
+		}
+
+		// 2. Hitung per jam dengan satu query GROUP BY
+		query := `SELECT EXTRACT(HOUR FROM appointment_date AT TIME ZONE $3)::int AS hour, COUNT(*)
+                  FROM appointments
+                  WHERE status NOT IN ($4, $5)
+                  AND ($1::timestamptz IS NULL OR appointment_date >= $1)
+                  AND ($2::timestamptz IS NULL OR appointment_date < $2)
+                  GROUP BY hour`
+
+		hours := make([]HourCount, 24)
+		err = withRetry(r.Context(), func() error {
+			for h := range hours {
+				hours[h] = HourCount{Hour: h}
+			}
+			rows, err := dbpool.Query(r.Context(), query, from, to, cfg.Location.String(), StatusCancelled, StatusHeld)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var h, n int
+				if err := rows.Scan(&h, &n); err != nil {
+					return err
+				}
+				hours[h].Count = n
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			http.Error(w, "Gagal membuat laporan jam sibuk", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hours)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDoctorAppointmentReportAggregates(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	// Periode jauh di depan agar janji temu test lain tidak ikut terhitung
+	day := tomorrowAt(cfg, 0).AddDate(6, 0, 0)
+	at := func(hour int) time.Time { return day.Add(time.Duration(hour) * time.Hour) }
+	busy := createTestDoctor(t, pool)
+	quiet := createTestDoctor(t, pool)
+	idle := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+
+	insertTestAppointment(t, pool, patientID, busy, at(8), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, busy, at(9), StatusRescheduled)
+	insertTestAppointment(t, pool, patientID, busy, at(10), StatusCheckedIn)
+	insertTestAppointment(t, pool, patientID, busy, at(11), StatusCancelled)
+	insertTestAppointment(t, pool, patientID, busy, at(12), StatusNeedsResolution)
+	insertTestAppointment(t, pool, patientID, busy, at(13), StatusHeld) // tidak dihitung
+	insertTestAppointment(t, pool, patientID, quiet, at(14), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, quiet, at(9).AddDate(0, 0, 1), StatusConfirmed) // di luar periode
+
+	target := fmt.Sprintf("/reports/doctor-appointments?from=%s&to=%s", day.Format(dateLayout), day.Format(dateLayout))
+	rec := serve(GetDoctorAppointmentReportHandler(pool, cfg), httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	reports := decodeJSON[[]DoctorAppointmentReport](t, rec)
+	index := map[int]int{}
+	for i, rep := range reports {
+		index[rep.DoctorID] = i
+	}
+
+	tests := []struct {
+		doctorID int
+		want     DoctorAppointmentReport
+	}{
+		{doctorID: busy, want: DoctorAppointmentReport{DoctorID: busy, DoctorName: "Dokter Test", Specialty: "Umum", Total: 5, Completed: 1, Cancelled: 1, NoShow: 1}},
+		{doctorID: quiet, want: DoctorAppointmentReport{DoctorID: quiet, DoctorName: "Dokter Test", Specialty: "Umum", Total: 1}},
+		{doctorID: idle, want: DoctorAppointmentReport{DoctorID: idle, DoctorName: "Dokter Test", Specialty: "Umum"}},
+	}
+	for _, tt := range tests {
+		i, ok := index[tt.doctorID]
+		if !ok {
+			t.Errorf("dokter %d tidak ada di laporan", tt.doctorID)
+			continue
+		}
+		if reports[i] != tt.want {
+			t.Errorf("laporan dokter %d = %+v, want %+v", tt.doctorID, reports[i], tt.want)
+		}
+	}
+	if index[busy] > index[quiet] || index[quiet] > index[idle] {
+		t.Errorf("urutan laporan = %d, %d, %d, want dari total terbanyak", index[busy], index[quiet], index[idle])
+	}
+}
+
+func TestPeakHoursReportBucketsByHour(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("Gagal memuat zona waktu: %v", err)
+	}
+	// Jam dihitung menurut zona waktu aplikasi, bukan UTC
+	cfg.Location = jakarta
+	// Periode jauh di depan agar janji temu test lain tidak ikut terhitung
+	day := tomorrowAt(cfg, 0).AddDate(7, 0, 0)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	first, second := createTestDoctor(t, pool), createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	insertTestAppointment(t, pool, patientID, first, at(9, 0), StatusConfirmed)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), second, at(9, 0), StatusCheckedIn)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), first, at(9, 30), StatusRescheduled)
+	insertTestAppointment(t, pool, patientID, first, at(14, 0), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, second, at(15, 0), StatusCancelled) // tidak dihitung
+	insertTestAppointment(t, pool, patientID, second, at(16, 0), StatusHeld)      // tidak dihitung
+
+	target := fmt.Sprintf("/reports/peak-hours?from=%s&to=%s", day.Format(dateLayout), day.Format(dateLayout))
+	rec := serve(GetPeakHoursReportHandler(pool, cfg), httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got := decodeJSON[[]HourCount](t, rec)
+	want := make([]HourCount, 24)
+	for h := range want {
+		want[h] = HourCount{Hour: h}
+	}
+	want[9].Count = 3
+	want[14].Count = 1
+	if !slices.Equal(got, want) {
+		t.Fatalf("jam sibuk = %+v, want %+v", got, want)
+	}
+}
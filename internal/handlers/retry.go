@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pengaturan retry untuk query baca.
+const (
+	readRetryAttempts = 3
+	readRetryBackoff  = 50 * time.Millisecond
+)
+
+// isTransientError mengecek apakah error database bersifat sementara sehingga
+// aman untuk dicoba lagi: serialization failure, deadlock, admin shutdown,
+// koneksi terputus, atau error yang menurut pgconn belum sempat dikirim ke server.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, "08") // connection_exception
+	}
+	return pgconn.SafeToRetry(err)
+}
+
+// withRetry menjalankan fn dan mengulanginya (dengan jeda yang makin panjang)
+// jika gagal karena error transient. Hanya untuk operasi yang idempoten,
+// seperti query baca; fn harus membuang hasil dari percobaan sebelumnya.
+func withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= readRetryAttempts; attempt++ {
+		err = fn()
+		if err == nil || !isTransientError(err) || attempt == readRetryAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(readRetryBackoff * time.Duration(attempt)):
+		}
+	}
+	return err
+}
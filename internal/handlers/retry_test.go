@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithRetryRetriesTransientError(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return &pgconn.PgError{Code: "40001"} // serialization_failure
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("fn dipanggil %d kali, want 2", calls)
+	}
+}
+
+func TestWithRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), func() error {
+		calls++
+		return &pgconn.PgError{Code: "40P01"} // deadlock_detected
+	})
+	if err == nil {
+		t.Fatal("withRetry seharusnya mengembalikan error terakhir")
+	}
+	if calls != readRetryAttempts {
+		t.Fatalf("fn dipanggil %d kali, want %d", calls, readRetryAttempts)
+	}
+}
+
+func TestWithRetryDoesNotRetryPermanentError(t *testing.T) {
+	for _, permanent := range []error{
+		&pgconn.PgError{Code: "23505"}, // unique_violation
+		errors.New("no rows in result set"),
+	} {
+		calls := 0
+		err := withRetry(context.Background(), func() error {
+			calls++
+			return permanent
+		})
+		if !errors.Is(err, permanent) {
+			t.Errorf("withRetry = %v, want %v", err, permanent)
+		}
+		if calls != 1 {
+			t.Errorf("%v: fn dipanggil %d kali, want 1", permanent, calls)
+		}
+	}
+}
+
+func TestWithRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	withRetry(ctx, func() error {
+		calls++
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if calls != 1 {
+		t.Fatalf("fn dipanggil %d kali setelah context dibatalkan, want 1", calls)
+	}
+}
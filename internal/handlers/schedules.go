@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WeekdayScheduleResponse adalah jam kerja seorang dokter pada satu hari tertentu.
+type WeekdayScheduleResponse struct {
+	DoctorID   int    `json:"doctorId"`
+	DoctorName string `json:"doctorName"`
+	Specialty  string `json:"specialty"`
+	DayOfWeek  int    `json:"dayOfWeek"`
+	StartTime  string `json:"startTime"`
+	EndTime    string `json:"endTime"`
+}
+
+// formatClock mengubah kolom TIME dari database ke string HH:MM:SS.
+func formatClock(t pgtype.Time) string {
+	d := time.Duration(t.Microseconds) * time.Microsecond
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// parseScheduleTimes memvalidasi startTime dan endTime (HH:MM:SS) pada req
+// dan mengembalikannya sebagai offset dari tengah malam. Jika allowOvernight
+// aktif, endTime sebelum startTime berarti shift malam dan end lebih dari 24 jam.
+// Pesan error yang dikembalikan siap dikirim sebagai response 422.
+func parseScheduleTimes(req ScheduleRequest, allowOvernight bool) (start, end time.Duration, err error) {
+	// Validasi #1 & #2: Cek format waktu
+	timeLayout := "15:04:05" // Format HH:MM:SS
+	startTime, err := time.Parse(timeLayout, req.StartTime)
+	if err != nil {
+		return 0, 0, errors.New("Format startTime tidak valid atau kosong, harus 'HH:MM:SS'")
+	}
+	endTime, err := time.Parse(timeLayout, req.EndTime)
+	if err != nil {
+		return 0, 0, errors.New("Format endTime tidak valid atau kosong, harus 'HH:MM:SS'")
+	}
+
+	// Validasi #3: Cek urutan waktu (shift malam boleh berakhir keesokan harinya)
+	overnight := allowOvernight && startTime.After(endTime)
+	if !overnight && (startTime.After(endTime) || startTime.Equal(endTime)) {
+		return 0, 0, errors.New("startTime harus sebelum endTime.")
+	}
+
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	start, end = startTime.Sub(midnight), endTime.Sub(midnight)
+	if overnight {
+		end += 24 * time.Hour
+	}
+	return start, end, nil
+}
+
+// PreviewScheduleChangeHandler menampilkan janji temu mendatang yang akan
+// berada di luar jam kerja jika jadwal dokter pada hari {day} diganti dengan
+// startTime/endTime di body (POST /doctors/{id}/schedules/{day}/preview).
+// Jadwal tidak diubah. Aturan "di dalam jam kerja" sama dengan validateAppointmentSlot,
+// termasuk janji temu dini hari keesokan harinya yang masuk shift malam.
+func PreviewScheduleChangeHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dokter, hari, dan jadwal yang diusulkan
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		day, err := parseWeekday(r.PathValue("day"), "day")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req ScheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		newStart, newEnd, err := parseScheduleTimes(req, cfg.AllowOvernightSchedules)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		// 2. Ambil jadwal saat ini (untuk shift malam yang berlanjut ke hari berikutnya)
+		var exists bool
+		if err := dbpool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", doctorID).Scan(&exists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		_, currentEnd, _, err := getWorkingHours(ctx, dbpool, doctorID, day)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
+			return
+		}
+		loc, err := doctorLocation(ctx, dbpool, doctorID, cfg)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Ambil janji temu mendatang dokter
+		rows, err := dbpool.Query(ctx, `SELECT `+appointmentColumns+` FROM appointments
+                  WHERE doctor_id = $1 AND appointment_date > NOW() AND `+activeAppointmentCondition+`
+                  ORDER BY appointment_date, id`, doctorID)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		var appointments []Appointment
+		for rows.Next() {
+			var a Appointment
+			if err := scanAppointment(rows, &a); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data janji temu", http.StatusInternalServerError)
+				return
+			}
+			appointments = append(appointments, a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Saring janji temu shift hari {day} yang tidak lagi masuk jadwal baru
+		nextDay := day%7 + 1
+		outside := []Appointment{}
+		for _, a := range appointments {
+			date := a.AppointmentDate.In(loc)
+			midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+			offset := date.Sub(midnight)
+
+			var inNew bool
+			switch weekday := isoWeekday(date); {
+			case weekday == day:
+				inNew = offset >= newStart && offset <= newEnd
+			case weekday == nextDay && currentEnd > 24*time.Hour && offset+24*time.Hour <= currentEnd:
+				// Dini hari yang saat ini masuk shift malam hari {day}
+				inNew = newEnd > 24*time.Hour && offset+24*time.Hour <= newEnd
+			default:
+				continue
+			}
+			if !inNew {
+				outside = append(outside, a)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(outside)
+	}
+}
+
+// GetSchedulesByDayHandler mengembalikan semua dokter yang praktik pada hari
+// ?day= (1 = Senin ... 7 = Minggu) beserta jam kerjanya, urut dari jam mulai.
+func GetSchedulesByDayHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi parameter hari
+		day, err := parseWeekday(r.URL.Query().Get("day"), "day")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil jadwal hari tersebut beserta data dokternya
+		query := `SELECT d.id, d.name, d.specialty, s.day_of_week, s.start_time, s.end_time
+                  FROM doctor_schedules s
+                  JOIN doctors d ON s.doctor_id = d.id
+                  WHERE s.day_of_week = $1
+                  ORDER BY s.start_time, d.name`
+
+		rows, err := dbpool.Query(r.Context(), query, day)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		// 3. Looping melalui hasil dan masukkan ke dalam slice
+		schedules := []WeekdayScheduleResponse{}
+		for rows.Next() {
+			var s WeekdayScheduleResponse
+			var startTime, endTime pgtype.Time
+			if err := rows.Scan(&s.DoctorID, &s.DoctorName, &s.Specialty, &s.DayOfWeek, &startTime, &endTime); err != nil {
+				http.Error(w, "Gagal memindai data jadwal", http.StatusInternalServerError)
+				return
+			}
+			s.StartTime = formatClock(startTime)
+			s.EndTime = formatClock(endTime)
+			schedules = append(schedules, s)
+		}
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(schedules)
+	}
+}
+
+// GetDoctorScheduleByDayHandler mengembalikan jadwal kerja dokter untuk satu
+// hari saja (GET /doctors/{id}/schedules/{day}), atau 404 jika dokter tidak
+// praktik di hari tersebut.
+func GetDoctorScheduleByDayHandler(dbpool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID dokter dan hari
+		doctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		day, err := parseWeekday(r.PathValue("day"), "day")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil jadwal hari tersebut
+		s := ScheduleResponse{DayOfWeek: day}
+		var startTime, endTime pgtype.Time
+		err = withRetry(r.Context(), func() error {
+			return dbpool.QueryRow(r.Context(), "SELECT start_time, end_time FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = $2", doctorID, day).Scan(&startTime, &endTime)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Jadwal dokter untuk hari tersebut tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data jadwal", http.StatusInternalServerError)
+			return
+		}
+		s.StartTime = formatClock(startTime)
+		s.EndTime = formatClock(endTime)
+
+		// 3. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// DoctorWithSchedules adalah data dokter beserta jadwal mingguannya.
+type DoctorWithSchedules struct {
+	Doctor
+	Schedules []ScheduleResponse `json:"schedules"`
+}
+
+// writeDoctorsWithSchedules mengirim daftar dokter (GET /doctors?include=schedules)
+// dengan jadwal mingguan yang disematkan, diambil dengan satu query LEFT JOIN
+// agar tidak perlu satu query per dokter.
+func writeDoctorsWithSchedules(w http.ResponseWriter, r *http.Request, dbpool *pgxpool.Pool, limit, offset int) {
+	query := `SELECT ` + qualifiedDoctorColumns("d") + `, s.day_of_week, s.start_time, s.end_time
+              FROM doctors d
+              LEFT JOIN doctor_schedules s ON s.doctor_id = d.id
+              ORDER BY d.id, s.day_of_week`
+
+	var doctors []DoctorWithSchedules
+	err := withRetry(r.Context(), func() error {
+		doctors = nil
+		rows, err := dbpool.Query(r.Context(), query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var d Doctor
+			var day pgtype.Int4
+			var startTime, endTime pgtype.Time
+			if err := scanDoctor(rows, &d, &day, &startTime, &endTime); err != nil {
+				return err
+			}
+			// Baris diurutkan per dokter, jadi dokter baru selalu di akhir slice
+			if len(doctors) == 0 || doctors[len(doctors)-1].ID != d.ID {
+				doctors = append(doctors, DoctorWithSchedules{Doctor: d, Schedules: []ScheduleResponse{}})
+			}
+			if day.Valid {
+				last := &doctors[len(doctors)-1]
+				last.Schedules = append(last.Schedules, ScheduleResponse{
+					DayOfWeek: int(day.Int32),
+					StartTime: formatClock(startTime),
+					EndTime:   formatClock(endTime),
+				})
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+		return
+	}
+
+	if doctors == nil {
+		doctors = []DoctorWithSchedules{}
+	}
+
+	setPaginationHeaders(w, r, len(doctors), limit, offset)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paginate(doctors, limit, offset))
+}
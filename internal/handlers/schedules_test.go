@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// findSchedule mengembalikan jadwal milik doctorID dari schedules.
+func findSchedule(schedules []WeekdayScheduleResponse, doctorID int) (WeekdayScheduleResponse, bool) {
+	for _, s := range schedules {
+		if s.DoctorID == doctorID {
+			return s, true
+		}
+	}
+	return WeekdayScheduleResponse{}, false
+}
+
+func TestGetSchedulesByDay(t *testing.T) {
+	pool := testPool(t, nil)
+	doctorID := createTestDoctor(t, pool)
+	// Dokter ini tidak praktik hari Minggu
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = 7", doctorID)
+	handler := GetSchedulesByDayHandler(pool)
+
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/schedules?day=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("day=1: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	got, ok := findSchedule(decodeJSON[[]WeekdayScheduleResponse](t, rec), doctorID)
+	want := WeekdayScheduleResponse{DoctorID: doctorID, DoctorName: "Dokter Test", Specialty: "Umum", DayOfWeek: 1, StartTime: "08:00:00", EndTime: "16:00:00"}
+	if !ok || got != want {
+		t.Fatalf("day=1: jadwal dokter = %+v (ada: %v), want %+v", got, ok, want)
+	}
+
+	rec = serve(handler, httptest.NewRequest(http.MethodGet, "/schedules?day=7", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("day=7: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if got, ok := findSchedule(decodeJSON[[]WeekdayScheduleResponse](t, rec), doctorID); ok {
+		t.Fatalf("day=7: dokter tanpa jadwal Minggu ikut dikembalikan: %+v", got)
+	}
+}
+
+func TestGetSchedulesByDayRejectsInvalidDay(t *testing.T) {
+	// Hari divalidasi sebelum ada query, jadi pool tidak dibutuhkan.
+	for _, day := range []string{"", "0", "8", "senin"} {
+		rec := serve(GetSchedulesByDayHandler(nil), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/schedules?day=%s", day), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("day=%q: status = %d, want 400", day, rec.Code)
+		}
+	}
+}
+
+const scheduleByDayRoute = "GET /doctors/{id}/schedules/{day}"
+
+func TestGetDoctorScheduleByDay(t *testing.T) {
+	pool := testPool(t, nil)
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week = 7", doctorID)
+	handler := routed(scheduleByDayRoute, GetDoctorScheduleByDayHandler(pool))
+
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/doctors/%d/schedules/1", doctorID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("hari 1: status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	want := ScheduleResponse{DayOfWeek: 1, StartTime: "08:00:00", EndTime: "16:00:00"}
+	if got := decodeJSON[ScheduleResponse](t, rec); got != want {
+		t.Errorf("hari 1: jadwal = %+v, want %+v", got, want)
+	}
+
+	rec = serve(handler, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/doctors/%d/schedules/7", doctorID), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("hari tanpa jadwal: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestGetDoctorScheduleByDayRejectsInvalidDay(t *testing.T) {
+	handler := routed(scheduleByDayRoute, GetDoctorScheduleByDayHandler(nil))
+	for _, day := range []string{"0", "8", "senin"} {
+		rec := serve(handler, httptest.NewRequest(http.MethodGet, "/doctors/1/schedules/"+day, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("hari %q: status = %d, want 400", day, rec.Code)
+		}
+	}
+}
+
+func TestGetAllDoctorsEmbedsSchedulesOnlyWhenRequested(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1 AND day_of_week > 2", doctorID)
+	// Halaman berisi tepat dokter test ini (daftar diurutkan per id).
+	var offset int
+	if err := pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM doctors WHERE id < $1", doctorID).Scan(&offset); err != nil {
+		t.Fatalf("Gagal menghitung posisi dokter test: %v", err)
+	}
+	handler := GetAllDoctorsHandler(pool, NewDoctorCache(cfg.DoctorCacheTTL), cfg)
+
+	list := func(query string) map[string]json.RawMessage {
+		t.Helper()
+		target := fmt.Sprintf("/doctors?limit=1&offset=%d%s", offset, query)
+		rec := serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200 (%s)", target, rec.Code, rec.Body)
+		}
+		doctors := decodeJSON[[]map[string]json.RawMessage](t, rec)
+		if len(doctors) != 1 || string(doctors[0]["id"]) != strconv.Itoa(doctorID) {
+			t.Fatalf("%s: dokter = %v, want hanya dokter %d", target, doctors, doctorID)
+		}
+		return doctors[0]
+	}
+
+	if _, ok := list("")["schedules"]; ok {
+		t.Error("tanpa include: jadwal ikut disematkan")
+	}
+
+	var schedules []ScheduleResponse
+	if err := json.Unmarshal(list("&include=schedules")["schedules"], &schedules); err != nil {
+		t.Fatalf("include=schedules: schedules tidak valid: %v", err)
+	}
+	want := []ScheduleResponse{
+		{DayOfWeek: 1, StartTime: "08:00:00", EndTime: "16:00:00"},
+		{DayOfWeek: 2, StartTime: "08:00:00", EndTime: "16:00:00"},
+	}
+	if !slices.Equal(schedules, want) {
+		t.Errorf("include=schedules: jadwal = %+v, want %+v", schedules, want)
+	}
+}
+
+func TestParseScheduleTimesOvernight(t *testing.T) {
+	tests := []struct {
+		start, end     string
+		allowOvernight bool
+		wantEnd        time.Duration
+		wantErr        bool
+	}{
+		{start: "08:00:00", end: "16:00:00", wantEnd: 16 * time.Hour},
+		{start: "22:00:00", end: "06:00:00", wantErr: true},
+		{start: "22:00:00", end: "06:00:00", allowOvernight: true, wantEnd: 30 * time.Hour},
+		{start: "10:00:00", end: "10:00:00", allowOvernight: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, end, err := parseScheduleTimes(ScheduleRequest{StartTime: tt.start, EndTime: tt.end}, tt.allowOvernight)
+		if (err != nil) != tt.wantErr || (err == nil && end != tt.wantEnd) {
+			t.Errorf("%s-%s (overnight %v): end = %s, err = %v; want end %s, error %v", tt.start, tt.end, tt.allowOvernight, end, err, tt.wantEnd, tt.wantErr)
+		}
+	}
+}
+
+func TestBookingWithinOvernightSchedule(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.AllowOvernightSchedules = true
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1", doctorID)
+
+	// Shift malam hanya pada hari besok: 22:00 sampai 06:00 lusa.
+	day := isoWeekday(tomorrowAt(cfg, 0))
+	handler := routed("POST /doctors/{id}/schedules", AddDoctorScheduleHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/schedules", doctorID),
+		ScheduleRequest{DayOfWeek: day, StartTime: "22:00:00", EndTime: "06:00:00"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("tambah shift malam: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+
+	nextDay := tomorrowAt(cfg, 0).AddDate(0, 0, 1)
+	tests := []struct {
+		name string
+		date time.Time
+		want int
+	}{
+		{"awal shift", tomorrowAt(cfg, 22), 0},
+		{"dini hari keesokannya", nextDay.Add(2 * time.Hour), 0},
+		{"setelah shift berakhir", nextDay.Add(6*time.Hour + 30*time.Minute), http.StatusConflict},
+		{"siang hari sebelum shift", tomorrowAt(cfg, 12), http.StatusConflict},
+	}
+	patientID := createTestPatient(t, pool)
+	for _, tt := range tests {
+		err := validateAppointmentSlot(context.Background(), pool, slotCheck{DoctorID: doctorID, PatientID: patientID, Date: tt.date}, cfg)
+		if got := slotErrorStatus(err); got != tt.want {
+			t.Errorf("%s: status = %d, want %d (err: %v)", tt.name, got, tt.want, err)
+		}
+	}
+
+	rec = serveJSON(t, CreateAppointmentHandler(pool, cfg), http.MethodPost, "/appointments", map[string]any{
+		"patientId": patientID, "doctorId": doctorID, "appointmentDate": nextDay.Add(2 * time.Hour),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Errorf("booking dini hari dalam shift malam: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestAddDoctorScheduleSameDayTwice(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1", doctorID)
+
+	handler := routed("POST /doctors/{id}/schedules", AddDoctorScheduleHandler(pool, cfg))
+	target := fmt.Sprintf("/doctors/%d/schedules", doctorID)
+	rec := serveJSON(t, handler, http.MethodPost, target, ScheduleRequest{DayOfWeek: 1, StartTime: "08:00:00", EndTime: "12:00:00"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("jadwal pertama: status = %d, want 201 (%s)", rec.Code, rec.Body)
+	}
+
+	rec = serveJSON(t, handler, http.MethodPost, target, ScheduleRequest{DayOfWeek: 1, StartTime: "13:00:00", EndTime: "16:00:00"})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("jadwal kedua pada hari yang sama: status = %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), uniqueConstraintMessages["doctor_schedules_doctor_id_day_of_week_key"]; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestPreviewScheduleChange(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+	before := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusConfirmed)
+	insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusConfirmed)
+	after := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 14), StatusConfirmed)
+	// Hari lain tidak terpengaruh perubahan jadwal hari besok
+	insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9).AddDate(0, 0, 1), StatusConfirmed)
+
+	day := isoWeekday(tomorrowAt(cfg, 0))
+	handler := routed("POST /doctors/{id}/schedules/{day}/preview", PreviewScheduleChangeHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/schedules/%d/preview", doctorID, day),
+		ScheduleRequest{StartTime: "10:00:00", EndTime: "13:00:00"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if got, want := appointmentIDs(decodeJSON[[]Appointment](t, rec)), []int{before, after}; !slices.Equal(got, want) {
+		t.Fatalf("janji temu di luar jadwal baru = %v, want %v", got, want)
+	}
+
+	// Pratinjau tidak mengubah jadwal yang tersimpan
+	start, end, _, err := getWorkingHours(context.Background(), pool, doctorID, day)
+	if err != nil || start != 8*time.Hour || end != 16*time.Hour {
+		t.Fatalf("jadwal setelah pratinjau = %s-%s (err: %v), want 8h-16h", start, end, err)
+	}
+}
+
+func TestAddDoctorScheduleMissingDoctorReturns404(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "DELETE FROM doctor_schedules WHERE doctor_id = $1", doctorID)
+	execSQL(t, pool, "DELETE FROM doctors WHERE id = $1", doctorID)
+
+	handler := routed("POST /doctors/{id}/schedules", AddDoctorScheduleHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/schedules", doctorID),
+		ScheduleRequest{DayOfWeek: 1, StartTime: "08:00:00", EndTime: "12:00:00"})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), "Dokter tidak ditemukan."; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Nama hari dan bulan dalam bahasa Indonesia untuk ringkasan janji temu.
+var (
+	indonesianWeekdays = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+	indonesianMonths   = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+)
+
+// statusLabels adalah keterangan status janji temu yang ramah untuk pasien.
+var statusLabels = map[string]string{
+	StatusConfirmed:       "Terkonfirmasi",
+	StatusRescheduled:     "Dijadwalkan ulang",
+	StatusCheckedIn:       "Sudah check-in",
+	StatusCancelled:       "Dibatalkan",
+	StatusHeld:            "Menunggu konfirmasi",
+	StatusNeedsResolution: "Perlu tindak lanjut",
+}
+
+// ClinicInfo adalah identitas klinik yang dicantumkan di ringkasan janji temu.
+type ClinicInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+}
+
+// AppointmentSummary adalah ringkasan janji temu yang siap dikirim ke pasien.
+type AppointmentSummary struct {
+	AppointmentID   int       `json:"appointmentId"`
+	PatientName     string    `json:"patientName"`
+	DoctorName      string    `json:"doctorName"`
+	Specialty       string    `json:"specialty"`
+	AppointmentDate Timestamp `json:"appointmentDate"`
+	// Date dan Time adalah jadwal yang sudah diformat menurut zona waktu
+	// aplikasi, mis. "Senin, 6 Januari 2025" dan "09.00 WIB".
+	Date        string     `json:"date"`
+	Time        string     `json:"time"`
+	Status      string     `json:"status"`
+	StatusLabel string     `json:"statusLabel"`
+	Clinic      ClinicInfo `json:"clinic"`
+	// Text adalah ringkasan lengkap dalam satu teks untuk isi SMS/email.
+	Text string `json:"text"`
+}
+
+// GetAppointmentSummaryHandler mengembalikan ringkasan janji temu yang mudah
+// dibaca untuk isi SMS/email konfirmasi (GET /appointments/{id}/summary).
+// Jadwal ditampilkan menurut zona waktu aplikasi. Default-nya JSON; dengan
+// ?format=text hanya teks ringkasannya yang dikirim (text/plain).
+func GetAppointmentSummaryHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Validasi ID dan format
+		appointmentID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID janji temu tidak valid", http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "text" {
+			http.Error(w, "format harus json atau text", http.StatusBadRequest)
+			return
+		}
+
+		// 2. Ambil janji temu beserta nama pasien dan dokternya
+		s := AppointmentSummary{
+			AppointmentID: appointmentID,
+			Clinic:        ClinicInfo{Name: cfg.ClinicName, Address: cfg.ClinicAddress, Phone: cfg.ClinicPhone},
+		}
+		query := `SELECT a.appointment_date, a.status, p.full_name, d.name, d.specialty
+                  FROM appointments a
+                  JOIN patients p ON a.patient_id = p.id
+                  JOIN doctors d ON a.doctor_id = d.id
+                  WHERE a.id = $1`
+		err = withRetry(r.Context(), func() error {
+			return dbpool.QueryRow(r.Context(), query, appointmentID).Scan(&s.AppointmentDate, &s.Status, &s.PatientName, &s.DoctorName, &s.Specialty)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Janji temu tidak ditemukan", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Format jadwal dan susun teks ringkasan
+		local := s.AppointmentDate.In(cfg.Location)
+		s.Date = fmt.Sprintf("%s, %d %s %d", indonesianWeekdays[local.Weekday()], local.Day(), indonesianMonths[local.Month()-1], local.Year())
+		s.Time = local.Format("15.04 MST")
+		s.StatusLabel = statusLabels[s.Status]
+		if s.StatusLabel == "" {
+			s.StatusLabel = s.Status
+		}
+		s.Text = appointmentSummaryText(s)
+
+		if format == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte(s.Text))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// appointmentSummaryText menyusun teks ringkasan janji temu dari s.
+func appointmentSummaryText(s AppointmentSummary) string {
+	lines := []string{
+		fmt.Sprintf("Janji temu #%d - %s", s.AppointmentID, s.Clinic.Name),
+		"Pasien: " + s.PatientName,
+		fmt.Sprintf("Dokter: %s (%s)", s.DoctorName, s.Specialty),
+		fmt.Sprintf("Jadwal: %s pukul %s", s.Date, s.Time),
+		"Status: " + s.StatusLabel,
+	}
+	if s.Clinic.Address != "" {
+		lines = append(lines, "Alamat: "+s.Clinic.Address)
+	}
+	if s.Clinic.Phone != "" {
+		lines = append(lines, "Telepon: "+s.Clinic.Phone)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
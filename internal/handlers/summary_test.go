@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const summaryRoute = "GET /appointments/{id}/summary"
+
+func TestGetAppointmentSummaryFormatsFields(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("Gagal memuat zona waktu: %v", err)
+	}
+	cfg.Location = jakarta
+	cfg.ClinicName = "Klinik Sehat"
+	cfg.ClinicAddress = "Jl. Merdeka 1"
+	cfg.ClinicPhone = "021-555"
+
+	doctorID := createTestDoctor(t, pool)
+	execSQL(t, pool, "UPDATE doctors SET name = 'dr. Andi', specialty = 'Anak' WHERE id = $1", doctorID)
+	patientID := createTestPatient(t, pool)
+	// 02:00 UTC adalah 09:00 WIB pada hari Senin.
+	id := insertTestAppointment(t, pool, patientID, doctorID, time.Date(2030, time.January, 7, 2, 0, 0, 0, time.UTC), StatusConfirmed)
+
+	handler := routed(summaryRoute, GetAppointmentSummaryHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodGet, fmt.Sprintf("/appointments/%d/summary", id), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	s := decodeJSON[AppointmentSummary](t, rec)
+	fields := []struct{ name, got, want string }{
+		{"patientName", s.PatientName, "Pasien Test"},
+		{"doctorName", s.DoctorName, "dr. Andi"},
+		{"specialty", s.Specialty, "Anak"},
+		{"date", s.Date, "Senin, 7 Januari 2030"},
+		{"time", s.Time, "09.00 WIB"},
+		{"status", s.Status, StatusConfirmed},
+		{"statusLabel", s.StatusLabel, "Terkonfirmasi"},
+		{"clinic.name", s.Clinic.Name, "Klinik Sehat"},
+		{"clinic.address", s.Clinic.Address, "Jl. Merdeka 1"},
+		{"clinic.phone", s.Clinic.Phone, "021-555"},
+	}
+	for _, f := range fields {
+		if f.got != f.want {
+			t.Errorf("%s = %q, want %q", f.name, f.got, f.want)
+		}
+	}
+	for _, line := range []string{
+		fmt.Sprintf("Janji temu #%d - Klinik Sehat", id),
+		"Dokter: dr. Andi (Anak)",
+		"Jadwal: Senin, 7 Januari 2030 pukul 09.00 WIB",
+		"Status: Terkonfirmasi",
+		"Alamat: Jl. Merdeka 1",
+		"Telepon: 021-555",
+	} {
+		if !strings.Contains(s.Text, line) {
+			t.Errorf("text tidak memuat %q:\n%s", line, s.Text)
+		}
+	}
+
+	// ?format=text mengirim teks yang sama sebagai text/plain
+	rec = serveJSON(t, handler, http.MethodGet, fmt.Sprintf("/appointments/%d/summary?format=text", id), nil)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("format=text: status = %d, Content-Type = %q, want 200 text/plain", rec.Code, ct)
+	}
+	if rec.Body.String() != s.Text {
+		t.Errorf("format=text: body = %q, want %q", rec.Body.String(), s.Text)
+	}
+
+	rec = serveJSON(t, handler, http.MethodGet, "/appointments/0/summary", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("janji temu yang tidak ada: status = %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestGetAppointmentSummaryRejectsUnknownFormat(t *testing.T) {
+	// Format divalidasi sebelum query, jadi tidak butuh database.
+	handler := routed(summaryRoute, GetAppointmentSummaryHandler(nil, testConfig()))
+	rec := serveJSON(t, handler, http.MethodGet, "/appointments/1/summary?format=xml", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+}
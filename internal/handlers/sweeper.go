@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StatusNeedsResolution menandai janji temu yang waktunya sudah lewat tetapi
+// pasien tidak pernah check-in, sehingga perlu ditindaklanjuti staf.
+const StatusNeedsResolution = "NEEDS_RESOLUTION"
+
+// RunSweeper membersihkan data secara berkala setiap cfg.SweepInterval:
+// menghapus hold yang kedaluwarsa dan, jika cfg.SweepMarkPastDue aktif,
+// menandai janji temu terjadwal yang sudah lewat sebagai NEEDS_RESOLUTION.
+// Fungsi ini berjalan sampai ctx dibatalkan, jadi panggil di goroutine sendiri.
+func RunSweeper(ctx context.Context, dbpool *pgxpool.Pool, cfg *config.Config) {
+	if cfg.SweepInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweep(ctx, dbpool, cfg)
+		}
+	}
+}
+
+// sweep menjalankan satu putaran pembersihan.
+func sweep(ctx context.Context, dbpool *pgxpool.Pool, cfg *config.Config) {
+	if err := expireStaleHolds(ctx, dbpool); err != nil {
+		log.Printf("Sweeper gagal menghapus hold kedaluwarsa: %v", err)
+	}
+
+	if !cfg.SweepMarkPastDue {
+		return
+	}
+	// Beri kelonggaran satu slot setelah jam janji temu sebelum ditandai
+	query := `UPDATE appointments SET status = $1
+              WHERE status IN ($2, $3) AND checked_in_at IS NULL
+              AND appointment_date < NOW() - $4::interval`
+	tag, err := dbpool.Exec(ctx, query, StatusNeedsResolution, StatusConfirmed, StatusRescheduled, cfg.SlotDuration)
+	if err != nil {
+		log.Printf("Sweeper gagal menandai janji temu yang terlewat: %v", err)
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("Sweeper menandai %d janji temu sebagai %s", n, StatusNeedsResolution)
+	}
+}
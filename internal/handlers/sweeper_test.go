@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSweepRemovesExpiredHoldAndMarksPastDue(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	cfg.SweepMarkPastDue = true
+	doctorID := createTestDoctor(t, pool)
+	patientID := createTestPatient(t, pool)
+
+	expiredHold := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 9), StatusHeld)
+	execSQL(t, pool, "UPDATE appointments SET hold_expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", expiredHold)
+	activeHold := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 10), StatusHeld)
+	execSQL(t, pool, "UPDATE appointments SET hold_expires_at = NOW() + INTERVAL '5 minutes' WHERE id = $1", activeHold)
+	overdue := insertTestAppointment(t, pool, patientID, doctorID, time.Now().Add(-2*time.Hour), StatusConfirmed)
+	upcoming := insertTestAppointment(t, pool, patientID, doctorID, tomorrowAt(cfg, 11), StatusConfirmed)
+
+	sweep(context.Background(), pool, cfg)
+
+	for _, tc := range []struct {
+		name string
+		id   int
+		want string
+	}{
+		{"hold kedaluwarsa", expiredHold, ""},
+		{"hold masih berlaku", activeHold, StatusHeld},
+		{"CONFIRMED yang terlewat", overdue, StatusNeedsResolution},
+		{"CONFIRMED yang akan datang", upcoming, StatusConfirmed},
+	} {
+		if got := appointmentStatus(pool, tc.id); got != tc.want {
+			t.Errorf("%s: status = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+)
+
+// RootResponse adalah struktur data untuk response endpoint root.
+type RootResponse struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Message string `json:"message"`
+}
+
+// RootHandler menampilkan nama dan versi API sesuai konfigurasi.
+func RootHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := RootResponse{
+			Name:    cfg.APIName,
+			Version: cfg.APIVersion,
+			Message: "Selamat Datang di " + cfg.APIName + " " + cfg.APIVersion,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// BuildInfo adalah metadata build yang diisi lewat -ldflags saat kompilasi.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// VersionHandler (GET /version) menampilkan metadata build untuk keperluan
+// troubleshooting. GoVersion selalu diisi dari runtime.Version().
+func VersionHandler(info BuildInfo) http.HandlerFunc {
+	info.GoVersion = runtime.Version()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	}
+}
+
+// Pinger adalah apa saja yang bisa dicek koneksinya, mis. *pgxpool.Pool.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// LivenessHandler (GET /livez, juga GET /healthz untuk pengecekan umum) selalu
+// mengembalikan 200 selama proses masih hidup.
+// Sengaja tidak mengecek database agar gangguan database sesaat tidak
+// membuat Kubernetes me-restart aplikasi berulang kali.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "ok"}`))
+	}
+}
+
+// ReadinessHandler (GET /readyz) mengecek apakah aplikasi siap melayani
+// request, yaitu database bisa di-ping. Jika tidak, kembalikan 503 supaya
+// traffic dialihkan sementara tanpa me-restart aplikasi.
+func ReadinessHandler(db Pinger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := db.Ping(ctx); err != nil {
+			log.Printf("Readiness check gagal: %v", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status": "unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}
+}
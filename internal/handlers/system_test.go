@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+)
+
+// fakePinger adalah Pinger yang selalu mengembalikan err.
+type fakePinger struct{ err error }
+
+func (p fakePinger) Ping(ctx context.Context) error { return p.err }
+
+func TestLivenessHandler(t *testing.T) {
+	for _, path := range []string{"/healthz", "/livez"} {
+		rec := httptest.NewRecorder()
+		LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestHealthzSkipsDBDeadline(t *testing.T) {
+	// /healthz tidak memakai database, jadi tidak boleh diberi batas waktu
+	// oleh LimitDBAcquire.
+	handler := LimitDBAcquire(time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			http.Error(w, "deadline tidak seharusnya dipasang", http.StatusInternalServerError)
+			return
+		}
+		LivenessHandler().ServeHTTP(w, r)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "database sehat", err: nil, want: http.StatusOK},
+		{name: "database mati", err: errors.New("connection refused"), want: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ReadinessHandler(fakePinger{err: tt.err}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRootHandlerUsesConfiguredMetadata(t *testing.T) {
+	t.Setenv("API_NAME", "API Klinik Sehat")
+	t.Setenv("API_VERSION", "v2")
+
+	rec := serve(RootHandler(config.Load()), httptest.NewRequest(http.MethodGet, "/", nil))
+	got := decodeJSON[RootResponse](t, rec)
+	want := RootResponse{Name: "API Klinik Sehat", Version: "v2", Message: "Selamat Datang di API Klinik Sehat v2"}
+	if got != want {
+		t.Fatalf("response = %+v, want %+v", got, want)
+	}
+}
+
+func TestVersionHandlerFieldsPresent(t *testing.T) {
+	handler := VersionHandler(BuildInfo{Version: "v1.2.0", Commit: "abc1234", BuildTime: "2025-01-06T09:00:00Z"})
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	// Dibaca sebagai map agar key yang hilang atau salah nama ikut terdeteksi
+	got := decodeJSON[map[string]string](t, rec)
+	for _, key := range []string{"version", "commit", "buildTime", "goVersion"} {
+		if got[key] == "" {
+			t.Errorf("%s kosong atau tidak ada: %v", key, got)
+		}
+	}
+	if got["goVersion"] != runtime.Version() {
+		t.Errorf("goVersion = %q, want %q", got["goVersion"], runtime.Version())
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// formatTimestamp adalah satu-satunya tempat timestamp diubah menjadi teks
+// (mis. untuk CSV): selalu RFC3339 dalam zona waktu aplikasi.
+func formatTimestamp(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// displayLocation adalah zona waktu aplikasi untuk menampilkan Timestamp,
+// diisi lewat SetDisplayLocation. nil berarti zona waktu nilainya sendiri.
+var displayLocation atomic.Pointer[time.Location]
+
+// SetDisplayLocation mengatur zona waktu yang dipakai saat Timestamp di-encode
+// ke JSON (cfg.Location). Dipanggil sekali saat aplikasi dimulai.
+func SetDisplayLocation(loc *time.Location) {
+	displayLocation.Store(loc)
+}
+
+// Timestamp adalah time.Time yang di-encode ke JSON sebagai RFC3339 tanpa
+// pecahan detik (mis. "2025-01-06T09:00:00+07:00"), sama seperti formatTimestamp.
+// Dipakai untuk semua timestamp di response pasien dan janji temu. Nilainya
+// bisa dipindai langsung dari kolom timestamptz dan dikirim sebagai parameter query.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON mengimplementasikan json.Marshaler. Nilai selalu ditampilkan
+// dalam zona waktu aplikasi, termasuk yang berasal dari input request dengan
+// offset lain, bukan hanya yang dipindai dari database.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if loc := displayLocation.Load(); loc != nil {
+		return json.Marshal(formatTimestamp(t.Time, loc))
+	}
+	return json.Marshal(t.Time.Format(time.RFC3339))
+}
+
+// ScanTimestamptz mengimplementasikan pgtype.TimestamptzScanner.
+func (t *Timestamp) ScanTimestamptz(v pgtype.Timestamptz) error {
+	t.Time = v.Time
+	return nil
+}
+
+// TimestamptzValue mengimplementasikan pgtype.TimestamptzValuer.
+func (t Timestamp) TimestamptzValue() (pgtype.Timestamptz, error) {
+	return pgtype.Timestamptz{Time: t.Time, Valid: true}, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatTimestampRoundTripAcrossDateBoundary(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	// 20:00 UTC tanggal 5 sudah tanggal 6 di WIB.
+	stored := time.Date(2025, 1, 5, 20, 0, 0, 0, time.UTC)
+
+	got := formatTimestamp(stored, wib)
+	if want := "2025-01-06T03:00:00+07:00"; got != want {
+		t.Fatalf("formatTimestamp = %q, want %q", got, want)
+	}
+	parsed, err := time.Parse(time.RFC3339, got)
+	if err != nil {
+		t.Fatalf("Hasil formatTimestamp tidak bisa diparse: %v", err)
+	}
+	if !parsed.Equal(stored) {
+		t.Errorf("round trip = %s, want %s", parsed.UTC(), stored)
+	}
+}
+
+func TestTimestampJSONUsesDisplayLocation(t *testing.T) {
+	SetDisplayLocation(time.FixedZone("WIB", 7*60*60))
+	t.Cleanup(func() { SetDisplayLocation(nil) })
+
+	// Input dengan offset lain tetap ditampilkan dalam zona waktu aplikasi.
+	var in Timestamp
+	if err := json.Unmarshal([]byte(`"2025-01-05T23:30:00-05:00"`), &in); err != nil {
+		t.Fatalf("Gagal mendekode timestamp: %v", err)
+	}
+	out, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Gagal meng-encode timestamp: %v", err)
+	}
+	if want := `"2025-01-06T11:30:00+07:00"`; string(out) != want {
+		t.Errorf("MarshalJSON = %s, want %s", out, want)
+	}
+
+	var back Timestamp
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("Gagal mendekode ulang timestamp: %v", err)
+	}
+	if !back.Equal(in.Time) {
+		t.Errorf("round trip = %s, want %s", back.UTC(), in.UTC())
+	}
+}
+
+func TestTimestampJSONFormat(t *testing.T) {
+	created := Timestamp{time.Date(2025, 1, 6, 2, 0, 0, 123456789, time.UTC)}
+	appt := Appointment{AppointmentDate: Timestamp{time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)}, CreatedAt: created, CheckedInAt: &created}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want map[string]string
+	}{
+		{"tanpa zona waktu aplikasi", nil, map[string]string{
+			"appointmentDate": "2025-01-06T09:00:00Z",
+			"createdAt":       "2025-01-06T02:00:00Z",
+			"checkedInAt":     "2025-01-06T02:00:00Z",
+		}},
+		{"zona waktu WIB", time.FixedZone("WIB", 7*60*60), map[string]string{
+			"appointmentDate": "2025-01-06T16:00:00+07:00",
+			"createdAt":       "2025-01-06T09:00:00+07:00",
+			"checkedInAt":     "2025-01-06T09:00:00+07:00",
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetDisplayLocation(tc.loc)
+			t.Cleanup(func() { SetDisplayLocation(nil) })
+
+			out, err := json.Marshal(appt)
+			if err != nil {
+				t.Fatalf("Gagal meng-encode janji temu: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("Gagal mendekode janji temu: %v", err)
+			}
+			for field, want := range tc.want {
+				if got[field] != want {
+					t.Errorf("%s = %v, want %s", field, got[field], want)
+				}
+			}
+
+			out, err = json.Marshal(Patient{CreatedAt: created})
+			if err != nil {
+				t.Fatalf("Gagal meng-encode pasien: %v", err)
+			}
+			var patient map[string]any
+			if err := json.Unmarshal(out, &patient); err != nil {
+				t.Fatalf("Gagal mendekode pasien: %v", err)
+			}
+			if patient["createdAt"] != tc.want["createdAt"] {
+				t.Errorf("createdAt pasien = %v, want %s", patient["createdAt"], tc.want["createdAt"])
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TransferRequest adalah body JSON untuk POST /doctors/{id}/transfer.
+type TransferRequest struct {
+	ToDoctorID int `json:"toDoctorId"`
+}
+
+// TransferFailure adalah janji temu yang tidak bisa dipindahkan beserta alasannya.
+type TransferFailure struct {
+	AppointmentID   int       `json:"appointmentId"`
+	AppointmentDate Timestamp `json:"appointmentDate"`
+	Reason          string    `json:"reason"`
+}
+
+// TransferResponse adalah hasil pemindahan janji temu antar dokter.
+type TransferResponse struct {
+	Transferred []int             `json:"transferred"`
+	Failed      []TransferFailure `json:"failed"`
+}
+
+// TransferDoctorAppointmentsHandler memindahkan semua janji temu mendatang
+// (yang belum dibatalkan) milik seorang dokter ke dokter lain, mis. saat dokter
+// berhenti praktik. Setiap janji temu divalidasi terhadap jadwal dokter tujuan
+// seperti booking biasa; yang tidak lolos tetap di dokter asal dan dilaporkan
+// di "failed". Semua perubahan dilakukan dalam satu transaksi.
+func TransferDoctorAppointmentsHandler(dbpool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Validasi ID dan body
+		fromDoctorID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "ID dokter tidak valid", http.StatusBadRequest)
+			return
+		}
+		var req TransferRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		if req.ToDoctorID == fromDoctorID {
+			http.Error(w, "Dokter tujuan harus berbeda dengan dokter asal.", http.StatusUnprocessableEntity)
+			return
+		}
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		// 2. Pastikan kedua dokter ada
+		var count int
+		err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM doctors WHERE id IN ($1, $2)", fromDoctorID, req.ToDoctorID).Scan(&count)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if count != 2 {
+			http.Error(w, "Dokter tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		// 3. Kunci janji temu mendatang milik dokter asal
+		rows, err := tx.Query(ctx, `SELECT id, patient_id, appointment_date FROM appointments
+                  WHERE doctor_id = $1 AND appointment_date > NOW() AND `+activeAppointmentCondition+`
+                  ORDER BY appointment_date, id
+                  FOR UPDATE`, fromDoctorID)
+		if err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+		type candidate struct {
+			id, patientID int
+			date          time.Time
+		}
+		var candidates []candidate
+		for rows.Next() {
+			var c candidate
+			if err := rows.Scan(&c.id, &c.patientID, &c.date); err != nil {
+				rows.Close()
+				http.Error(w, "Gagal memindai data janji temu", http.StatusInternalServerError)
+				return
+			}
+			candidates = append(candidates, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Gagal mengambil data janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Validasi dan pindahkan satu per satu
+		resp := TransferResponse{Transferred: []int{}, Failed: []TransferFailure{}}
+		for _, c := range candidates {
+			fail := func(reason string) {
+				resp.Failed = append(resp.Failed, TransferFailure{AppointmentID: c.id, AppointmentDate: Timestamp{c.date}, Reason: reason})
+			}
+
+			err := validateAppointmentSlot(ctx, tx, slotCheck{DoctorID: req.ToDoctorID, PatientID: c.patientID, Date: c.date, ExcludeID: c.id}, cfg)
+			var slotErr *SlotError
+			if errors.As(err, &slotErr) {
+				fail(slotErr.Message)
+				continue
+			}
+			if err != nil {
+				writeSlotError(w, err)
+				return
+			}
+
+			// Slot dokter tujuan sudah terisi? Dicek dulu agar unique index tidak
+			// membatalkan seluruh transaksi.
+			var taken bool
+			err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM appointments
+                      WHERE doctor_id = $1 AND appointment_date = $2 AND `+activeAppointmentCondition+`)`, req.ToDoctorID, c.date).Scan(&taken)
+			if err != nil {
+				http.Error(w, "Gagal memeriksa slot dokter tujuan", http.StatusInternalServerError)
+				return
+			}
+			if taken {
+				fail("Slot waktu tersebut sudah terisi pada dokter tujuan.")
+				continue
+			}
+
+			if _, err := tx.Exec(ctx, "UPDATE appointments SET doctor_id = $1 WHERE id = $2", req.ToDoctorID, c.id); err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					http.Error(w, "Jadwal dokter tujuan berubah selama pemindahan. Silakan coba lagi.", http.StatusConflict)
+					return
+				}
+				log.Printf("Gagal memindahkan janji temu %d: %v", c.id, err)
+				http.Error(w, "Gagal memindahkan janji temu", http.StatusInternalServerError)
+				return
+			}
+			resp.Transferred = append(resp.Transferred, c.id)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit pemindahan janji temu: %v", err)
+			http.Error(w, "Gagal memindahkan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 5. Kirim response JSON
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
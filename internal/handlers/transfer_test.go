@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestTransferDoctorAppointmentsReportsUntransferable(t *testing.T) {
+	pool := testPool(t, nil)
+	cfg := testConfig()
+	from := createTestDoctor(t, pool)
+	to := createTestDoctor(t, pool)
+	// Dokter tujuan hanya praktik sampai pukul 12:00.
+	execSQL(t, pool, "UPDATE doctor_schedules SET end_time = '12:00' WHERE doctor_id = $1", to)
+	patientID := createTestPatient(t, pool)
+
+	movable := insertTestAppointment(t, pool, patientID, from, tomorrowAt(cfg, 9), StatusConfirmed)
+	slotTaken := insertTestAppointment(t, pool, patientID, from, tomorrowAt(cfg, 10), StatusConfirmed)
+	insertTestAppointment(t, pool, createTestPatient(t, pool), to, tomorrowAt(cfg, 10), StatusConfirmed)
+	afterHours := insertTestAppointment(t, pool, patientID, from, tomorrowAt(cfg, 13), StatusConfirmed)
+	cancelled := insertTestAppointment(t, pool, patientID, from, tomorrowAt(cfg, 11), StatusCancelled)
+	past := insertTestAppointment(t, pool, patientID, from, tomorrowAt(cfg, 9).AddDate(0, 0, -2), StatusCheckedIn)
+
+	handler := routed("POST /doctors/{id}/transfer", TransferDoctorAppointmentsHandler(pool, cfg))
+	rec := serveJSON(t, handler, http.MethodPost, fmt.Sprintf("/doctors/%d/transfer", from), TransferRequest{ToDoctorID: to})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	resp := decodeJSON[TransferResponse](t, rec)
+	if want := []int{movable}; !slices.Equal(resp.Transferred, want) {
+		t.Errorf("transferred = %v, want %v", resp.Transferred, want)
+	}
+	var failed []int
+	for _, f := range resp.Failed {
+		if f.Reason == "" {
+			t.Errorf("janji temu %d gagal dipindahkan tanpa alasan", f.AppointmentID)
+		}
+		failed = append(failed, f.AppointmentID)
+	}
+	if want := []int{slotTaken, afterHours}; !slices.Equal(failed, want) {
+		t.Errorf("failed = %v, want %v", failed, want)
+	}
+
+	for id, want := range map[int]int{movable: to, slotTaken: from, afterHours: from, cancelled: from, past: from} {
+		var doctorID int
+		if err := pool.QueryRow(context.Background(), "SELECT doctor_id FROM appointments WHERE id = $1", id).Scan(&doctorID); err != nil {
+			t.Fatalf("Gagal mengambil janji temu %d: %v", id, err)
+		}
+		if doctorID != want {
+			t.Errorf("janji temu %d: doctor_id = %d, want %d", id, doctorID, want)
+		}
+	}
+}
+
+func TestTransferToSameDoctorReturns422(t *testing.T) {
+	// Ditolak sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := routed("POST /doctors/{id}/transfer", TransferDoctorAppointmentsHandler(nil, testConfig()))
+	rec := serveJSON(t, handler, http.MethodPost, "/doctors/7/transfer", TransferRequest{ToDoctorID: 7})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// KTPValidator memvalidasi nomor KTP pasien. Deployment yang butuh aturan lebih
+// ketat (mis. mengecek kode wilayah atau tanggal lahir di dalam NIK) cukup
+// menyediakan implementasi sendiri tanpa mengubah handler.
+// Error yang dikembalikan akan dikirim apa adanya ke client.
+type KTPValidator interface {
+	ValidateKTP(ktp string) error
+}
+
+// NumericKTPValidator adalah validator default: KTP harus 16 digit angka.
+type NumericKTPValidator struct{}
+
+var digitsOnly = regexp.MustCompile("^[0-9]+$")
+
+// ValidateKTP mengimplementasikan KTPValidator.
+func (NumericKTPValidator) ValidateKTP(ktp string) error {
+	if len(ktp) != 16 {
+		return errors.New("Nomor KTP harus 16 digit")
+	}
+	if !digitsOnly.MatchString(ktp) {
+		return errors.New("Nomor KTP harus berupa angka.")
+	}
+	return nil
+}
+
+// FieldError adalah satu kegagalan validasi pada field tertentu.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors mengumpulkan semua kegagalan validasi sebuah payload agar
+// client bisa memperbaiki semuanya sekaligus, bukan satu per satu.
+type ValidationErrors struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (v *ValidationErrors) Error() string {
+	msgs := make([]string, len(v.Errors))
+	for i, e := range v.Errors {
+		msgs[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// add mencatat satu kegagalan validasi.
+func (v *ValidationErrors) add(field, message string) {
+	v.Errors = append(v.Errors, FieldError{Field: field, Message: message})
+}
+
+// err mengembalikan nil jika tidak ada kegagalan, atau v itu sendiri.
+func (v *ValidationErrors) err() error {
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v
+}
+
+// writeValidationError mengirim error validasi sebagai 422 dengan body
+// {"errors":[{"field":...,"message":...}]}. Error lain dikirim sebagai 400.
+//
+// Aturan kode status di seluruh handler: 400 untuk request yang tidak bisa
+// dibaca (JSON rusak, ID/query parameter tidak valid), 422 untuk body yang
+// terbaca dengan benar tetapi isinya melanggar aturan bisnis.
+func writeValidationError(w http.ResponseWriter, err error) {
+	var verr *ValidationErrors
+	if !errors.As(err, &verr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(verr)
+}
+
+// decodeErrorMessage mengubah error dari json.Decoder menjadi pesan untuk
+// response 400. Body kosong (io.EOF) diberi pesan tersendiri. Nomor KTP yang
+// dikirim sebagai angka JSON ditolak dengan pesan khusus, karena angka 0 di
+// depan KTP akan hilang jika tidak dikirim sebagai string.
+func decodeErrorMessage(err error) string {
+	if errors.Is(err, io.EOF) {
+		return "Request body kosong"
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && (typeErr.Field == "ktpNumber" || strings.HasSuffix(typeErr.Field, ".ktpNumber")) {
+		return `ktpNumber harus dikirim sebagai string, mis. "0123456789012345", agar angka 0 di depan tidak hilang.`
+	}
+	return "Request body tidak valid"
+}
+
+// decodeTolerantJSON mendekode body JSON ke v seperti json.Decoder biasa, tetapi
+// key snake_case (mis. "date_of_birth") juga diterima sebagai camelCase
+// ("dateOfBirth"), termasuk pada objek bersarang. Jika kedua bentuk dikirim,
+// key camelCase yang dipakai. Response tetap memakai camelCase.
+func decodeTolerantJSON(body io.Reader, v any) error {
+	dec := json.NewDecoder(body)
+	dec.UseNumber() // agar angka besar tidak berubah lewat float64
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(camelCaseKeys(raw))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// camelCaseKeys mengubah semua key snake_case di dalam hasil dekode JSON
+// menjadi camelCase secara rekursif.
+func camelCaseKeys(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, child := range val {
+			camel := snakeToCamel(k)
+			if _, exists := val[camel]; exists && camel != k {
+				continue
+			}
+			out[camel] = camelCaseKeys(child)
+		}
+		return out
+	case []any:
+		for i, child := range val {
+			val[i] = camelCaseKeys(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// snakeToCamel mengubah "date_of_birth" menjadi "dateOfBirth".
+// Key tanpa garis bawah dikembalikan apa adanya.
+func snakeToCamel(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+	parts := strings.Split(key, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// dobLayout adalah format tanggal lahir pasien (DD-MM-YYYY).
+const dobLayout = "02-01-2006"
+
+// normalizeName membuang spasi di awal/akhir nama dan merapatkan spasi
+// berlebih di tengah, mis. "  Budi   Santoso " menjadi "Budi Santoso".
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// validatePatient menormalkan lalu memvalidasi data pasien baru dan mengembalikan
+// tanggal lahir yang sudah dikonversi. Semua kegagalan dikumpulkan dalam *ValidationErrors.
+func validatePatient(p *Patient, ktpValidator KTPValidator) (time.Time, error) {
+	p.FullName = normalizeName(p.FullName)
+
+	var verr ValidationErrors
+	if err := ktpValidator.ValidateKTP(p.KTPNumber); err != nil {
+		verr.add("ktpNumber", err.Error())
+	}
+	if len(p.FullName) < 3 {
+		verr.add("fullName", "Nama lengkap minimal 3 karakter")
+	}
+
+	dob, err := time.Parse(dobLayout, p.DateOfBirth)
+	if err != nil {
+		verr.add("dateOfBirth", "Format tanggal lahir harus DD-MM-YYYY")
+	} else if dob.After(time.Now()) {
+		// Tanggal lahir tidak boleh di masa depan
+		verr.add("dateOfBirth", "Tanggal lahir tidak boleh ada di masa depan.")
+	}
+	return dob, verr.err()
+}
+
+// validateAppointmentRequest memeriksa field janji temu dari body request
+// sebelum ada query ke database: patientId dan doctorId harus bilangan positif.
+func validateAppointmentRequest(a Appointment) error {
+	var verr ValidationErrors
+	if a.PatientID <= 0 {
+		verr.add("patientId", "patientId harus berupa angka lebih dari 0.")
+	}
+	if a.DoctorID <= 0 {
+		verr.add("doctorId", "doctorId harus berupa angka lebih dari 0.")
+	}
+	return verr.err()
+}
+
+// validatePriority menormalkan prioritas janji temu (huruf besar; kosong
+// berarti ROUTINE) lalu memastikan nilainya dikenal.
+func validatePriority(priority *string) error {
+	*priority = strings.ToUpper(strings.TrimSpace(*priority))
+	if *priority == "" {
+		*priority = PriorityRoutine
+	}
+	var verr ValidationErrors
+	if !isKnownPriority(*priority) {
+		verr.add("priority", "priority harus ROUTINE, URGENT, atau EMERGENCY.")
+	}
+	return verr.err()
+}
+
+// maxReasonLength adalah panjang maksimum kolom alasan, baik alasan kunjungan
+// janji temu maupun alasan libur dokter (kolom VARCHAR(255)).
+const maxReasonLength = 255
+
+// validateReason menormalkan alasan kunjungan janji temu (spasi di awal/akhir
+// dibuang; string kosong berarti tanpa alasan) lalu memeriksa panjangnya.
+func validateReason(reason **string) error {
+	if *reason == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(**reason)
+	if trimmed == "" {
+		*reason = nil
+		return nil
+	}
+	*reason = &trimmed
+
+	var verr ValidationErrors
+	if utf8.RuneCountInString(trimmed) > maxReasonLength {
+		verr.add("reason", fmt.Sprintf("Alasan kunjungan maksimal %d karakter.", maxReasonLength))
+	}
+	return verr.err()
+}
+
+// maxStaffNotesLength adalah panjang maksimum catatan staf pada janji temu.
+const maxStaffNotesLength = 2000
+
+// validateStaffNotes menormalkan catatan staf seperti validateReason
+// (string kosong berarti tanpa catatan) lalu memeriksa panjangnya.
+func validateStaffNotes(notes **string) error {
+	if *notes == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(**notes)
+	if trimmed == "" {
+		*notes = nil
+		return nil
+	}
+	*notes = &trimmed
+
+	var verr ValidationErrors
+	if utf8.RuneCountInString(trimmed) > maxStaffNotesLength {
+		verr.add("staffNotes", fmt.Sprintf("Catatan staf maksimal %d karakter.", maxStaffNotesLength))
+	}
+	return verr.err()
+}
+
+// normalizeSpecialties merapikan daftar spesialisasi dokter: spasi dibuang,
+// nilai kosong dan duplikat (tanpa membedakan huruf besar/kecil) dihapus, dan
+// spesialisasi utama (jika diisi) selalu berada di urutan pertama.
+func normalizeSpecialties(primary string, specialties []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, s := range append([]string{primary}, specialties...) {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[strings.ToLower(s)] {
+			continue
+		}
+		seen[strings.ToLower(s)] = true
+		result = append(result, s)
+	}
+	return result
+}
+
+// maxBufferMinutes adalah jeda antar janji temu terbesar yang boleh diatur dokter.
+const maxBufferMinutes = 240
+
+// validateDoctor menormalkan lalu memvalidasi data dokter untuk pembuatan maupun
+// perubahan. Semua kegagalan dikumpulkan dalam *ValidationErrors. Jika
+// requireNIK false, NIK boleh kosong, tetapi formatnya tetap dicek bila diisi.
+func validateDoctor(d *Doctor, requireNIK bool) error {
+	d.Name = normalizeName(d.Name)
+	d.NIK = strings.TrimSpace(d.NIK)
+
+	var verr ValidationErrors
+	if d.NIK == "" && !requireNIK {
+		// NIK opsional di deployment ini
+	} else if len(d.NIK) != 10 {
+		verr.add("nik", "NIK dokter harus 10 digit")
+	} else if !digitsOnly.MatchString(d.NIK) {
+		verr.add("nik", "NIK harus berupa angka.")
+	}
+	if len(d.Name) < 3 {
+		verr.add("name", "Nama dokter minimal 3 karakter")
+	}
+	d.Specialty = strings.TrimSpace(d.Specialty)
+	d.Specialties = normalizeSpecialties(d.Specialty, d.Specialties)
+	if len(d.Specialties) == 0 {
+		verr.add("specialty", "Specialty tidak boleh kosong.")
+	} else if d.Specialty == "" {
+		d.Specialty = d.Specialties[0]
+	}
+	if d.Timezone != nil {
+		tz := strings.TrimSpace(*d.Timezone)
+		if tz == "" {
+			d.Timezone = nil
+		} else if _, err := time.LoadLocation(tz); err != nil {
+			verr.add("timezone", "Zona waktu tidak dikenal, gunakan nama IANA seperti Asia/Jakarta.")
+		} else {
+			d.Timezone = &tz
+		}
+	}
+	if d.ReminderLeadTime != nil {
+		lead, err := time.ParseDuration(strings.TrimSpace(*d.ReminderLeadTime))
+		if err != nil || lead <= 0 {
+			verr.add("reminderLeadTime", `reminderLeadTime harus berupa durasi positif, mis. "48h" atau "90m".`)
+		} else {
+			s := lead.String()
+			d.ReminderLeadTime = &s
+		}
+	}
+	if d.BufferMinutes < 0 || d.BufferMinutes > maxBufferMinutes {
+		verr.add("bufferMinutes", fmt.Sprintf("bufferMinutes harus antara 0 dan %d.", maxBufferMinutes))
+	}
+	return verr.err()
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jawaBaratKTPValidator hanya menerima KTP berawalan kode provinsi 32.
+type jawaBaratKTPValidator struct{}
+
+func (jawaBaratKTPValidator) ValidateKTP(ktp string) error {
+	if !strings.HasPrefix(ktp, "32") {
+		return errors.New("KTP harus terdaftar di Jawa Barat")
+	}
+	return nil
+}
+
+func TestValidatePatientUsesInjectedKTPValidator(t *testing.T) {
+	// Bukan 16 digit, tetapi lolos validator kustom.
+	p := Patient{KTPNumber: "32-ABC", FullName: "Budi Santoso", DateOfBirth: "01-01-1990"}
+	if _, err := validatePatient(&p, jawaBaratKTPValidator{}); err != nil {
+		t.Fatalf("validatePatient dengan validator kustom: %v", err)
+	}
+}
+
+func TestCreatePatientRejectsKTPWithInjectedValidator(t *testing.T) {
+	// Validasi gagal sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := CreatePatientHandler(nil, testConfig(), jawaBaratKTPValidator{})
+	rec := serveJSON(t, handler, http.MethodPost, "/patients", Patient{
+		KTPNumber: "3171012345678901", FullName: "Budi Santoso", DateOfBirth: "01-01-1990",
+	})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422 (%s)", rec.Code, rec.Body)
+	}
+	var body ValidationErrors
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("body bukan ValidationErrors: %v", err)
+	}
+	want := FieldError{Field: "ktpNumber", Message: "KTP harus terdaftar di Jawa Barat"}
+	if len(body.Errors) != 1 || body.Errors[0] != want {
+		t.Fatalf("errors = %+v, want [%+v]", body.Errors, want)
+	}
+}
+
+// errorFields mengembalikan nama field dari err yang berupa *ValidationErrors.
+func errorFields(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationErrors
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *ValidationErrors", err)
+	}
+	fields := make([]string, len(verr.Errors))
+	for i, e := range verr.Errors {
+		fields[i] = e.Field
+	}
+	return fields
+}
+
+func TestValidatePatientReportsAllErrors(t *testing.T) {
+	p := Patient{KTPNumber: "123", FullName: "Al", DateOfBirth: "1990-01-01"}
+	_, err := validatePatient(&p, NumericKTPValidator{})
+	got := strings.Join(errorFields(t, err), ",")
+	if want := "ktpNumber,fullName,dateOfBirth"; got != want {
+		t.Fatalf("field yang gagal = %s, want %s", got, want)
+	}
+}
+
+func TestValidateDoctorReportsAllErrors(t *testing.T) {
+	d := Doctor{NIK: "12", Name: "Al"}
+	got := strings.Join(errorFields(t, validateDoctor(&d, true)), ",")
+	if want := "nik,name,specialty"; got != want {
+		t.Fatalf("field yang gagal = %s, want %s", got, want)
+	}
+
+	// NIK wajib vs opsional (DOCTOR_NIK_REQUIRED)
+	tests := []struct {
+		name       string
+		nik        string
+		requireNIK bool
+		// want adalah field yang gagal, dipisahkan koma ("" jika valid).
+		want string
+	}{
+		{name: "wajib, kosong", nik: "", requireNIK: true, want: "nik"},
+		{name: "wajib, valid", nik: "1234567890", requireNIK: true, want: ""},
+		{name: "opsional, kosong", nik: "", requireNIK: false, want: ""},
+		{name: "opsional, valid", nik: "1234567890", requireNIK: false, want: ""},
+		{name: "opsional, format salah", nik: "12345", requireNIK: false, want: "nik"},
+		{name: "opsional, bukan angka", nik: "12345abcde", requireNIK: false, want: "nik"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Doctor{NIK: tt.nik, Name: "Dokter Test", Specialty: "Umum"}
+			err := validateDoctor(&d, tt.requireNIK)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("validateDoctor: %v, want valid", err)
+				}
+				return
+			}
+			if got := strings.Join(errorFields(t, err), ","); got != tt.want {
+				t.Fatalf("field yang gagal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// ktpAsNumberMessage adalah pesan 400 saat ktpNumber dikirim sebagai angka JSON.
+const ktpAsNumberMessage = `ktpNumber harus dikirim sebagai string, mis. "0123456789012345", agar angka 0 di depan tidak hilang.`
+
+func TestCreatePatientMalformedVersusInvalidBody(t *testing.T) {
+	// Kedua kasus gagal sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := CreatePatientHandler(nil, testConfig(), nil)
+	tests := []struct {
+		name string
+		body string
+		want int
+		// message, jika diisi, adalah isi response yang diharapkan.
+		message string
+	}{
+		{name: "JSON rusak", body: `{"ktpNumber": "3171`, want: http.StatusBadRequest},
+		{name: "body kosong", body: ``, want: http.StatusBadRequest, message: "Request body kosong"},
+		{name: "KTP sebagai angka", body: `{"ktpNumber": 3171012345678901}`, want: http.StatusBadRequest, message: ktpAsNumberMessage},
+		{name: "isi tidak valid", body: `{"ktpNumber": "123", "fullName": "Al", "dateOfBirth": "01-01-1990"}`, want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/patients", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); tt.message != "" && got != tt.message {
+				t.Fatalf("body = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizesNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantError bool
+	}{
+		{name: "hanya spasi", input: "     ", want: "", wantError: true},
+		{name: "pendek setelah di-trim", input: "   Jo", want: "Jo", wantError: true},
+		{name: "padding dan spasi ganda", input: "  Budi   Santoso ", want: "Budi Santoso"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Patient{KTPNumber: "3171012345678901", FullName: tt.input, DateOfBirth: "01-01-1990"}
+			_, err := validatePatient(&p, NumericKTPValidator{})
+			if p.FullName != tt.want {
+				t.Errorf("fullName = %q, want %q", p.FullName, tt.want)
+			}
+			if (err != nil) != tt.wantError {
+				t.Errorf("validatePatient error = %v, want error: %v", err, tt.wantError)
+			}
+
+			d := Doctor{NIK: "1234567890", Name: tt.input, Specialty: "Umum"}
+			err = validateDoctor(&d, true)
+			if d.Name != tt.want {
+				t.Errorf("name = %q, want %q", d.Name, tt.want)
+			}
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateDoctor error = %v, want error: %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestWalkInRejectsNumericKTP(t *testing.T) {
+	// Body ditolak sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := WalkInHandler(nil, testConfig(), nil)
+	body := `{"patient": {"ktpNumber": 171012345678901, "fullName": "Budi Santoso", "dateOfBirth": "01-01-1990"}, "doctorId": 1}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/walk-ins", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != ktpAsNumberMessage {
+		t.Fatalf("body = %q, want %q", got, ktpAsNumberMessage)
+	}
+}
+
+func TestCreateAppointmentRejectsNonPositiveIDs(t *testing.T) {
+	// Ditolak sebelum ada query, jadi pool tidak dibutuhkan.
+	handler := CreateAppointmentHandler(nil, testConfig())
+	tests := []struct {
+		name      string
+		patientID int
+		doctorID  int
+		fields    string
+	}{
+		{name: "keduanya nol", patientID: 0, doctorID: 0, fields: "patientId,doctorId"},
+		{name: "patientId negatif", patientID: -3, doctorID: 1, fields: "patientId"},
+		{name: "doctorId negatif", patientID: 1, doctorID: -1, fields: "doctorId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(t, handler, http.MethodPost, "/appointments", map[string]any{
+				"patientId": tt.patientID, "doctorId": tt.doctorID, "appointmentDate": "2030-01-07T09:00:00Z",
+			})
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422 (%s)", rec.Code, rec.Body)
+			}
+			var fields []string
+			for _, e := range decodeJSON[ValidationErrors](t, rec).Errors {
+				fields = append(fields, e.Field)
+			}
+			if got := strings.Join(fields, ","); got != tt.fields {
+				t.Fatalf("field yang gagal = %s, want %s", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeTolerantJSONAcceptsSnakeCase(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Patient
+	}{
+		{
+			name: "snake_case",
+			body: `{"ktp_number": "3171012345678901", "full_name": "Budi Santoso", "date_of_birth": "01-01-1990"}`,
+			want: Patient{KTPNumber: "3171012345678901", FullName: "Budi Santoso", DateOfBirth: "01-01-1990"},
+		},
+		{
+			name: "camelCase menang jika keduanya dikirim",
+			body: `{"fullName": "Budi Santoso", "full_name": "Budi", "date_of_birth": "01-01-1990"}`,
+			want: Patient{FullName: "Budi Santoso", DateOfBirth: "01-01-1990"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Patient
+			if err := decodeTolerantJSON(strings.NewReader(tt.body), &got); err != nil {
+				t.Fatalf("decodeTolerantJSON: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("patient = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateWithSnakeCaseBody(t *testing.T) {
+	// Validasi gagal sebelum ada query, jadi pool tidak dibutuhkan. Hanya
+	// field yang memang salah yang dilaporkan, dengan nama camelCase; field
+	// snake_case lainnya ikut terbaca.
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		want    string
+	}{
+		{
+			name:    "pasien",
+			handler: CreatePatientHandler(nil, testConfig(), nil),
+			body:    `{"ktp_number": "123", "full_name": "Budi Santoso", "date_of_birth": "01-01-1990"}`,
+			want:    "ktpNumber",
+		},
+		{
+			name:    "dokter",
+			handler: CreateDoctorHandler(nil, nil, testConfig()),
+			body:    `{"nik": "1234567890", "name": "Dokter Test", "specialty": "Umum", "reminder_lead_time": "-1h"}`,
+			want:    "reminderLeadTime",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422 (%s)", rec.Code, rec.Body)
+			}
+			var body ValidationErrors
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("body bukan ValidationErrors: %v", err)
+			}
+			if len(body.Errors) != 1 || body.Errors[0].Field != tt.want {
+				t.Fatalf("errors = %+v, want hanya field %s", body.Errors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gordonsinambela1987-cell/latihan-api-pasien-go/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WalkInRequest adalah body JSON untuk pendaftaran pasien walk-in.
+type WalkInRequest struct {
+	Patient         Patient   `json:"patient"`
+	DoctorID        int       `json:"doctorId"`
+	AppointmentDate time.Time `json:"appointmentDate"`
+	// Priority opsional, default ROUTINE (lihat validatePriority).
+	Priority string `json:"priority"`
+}
+
+// WalkInResponse berisi pasien (baru atau yang sudah ada) dan janji temunya.
+type WalkInResponse struct {
+	Patient        Patient     `json:"patient"`
+	PatientCreated bool        `json:"patientCreated"`
+	Appointment    Appointment `json:"appointment"`
+}
+
+// WalkInHandler mendaftarkan pasien walk-in dalam satu langkah: pasien dibuat
+// jika nomor KTP-nya belum terdaftar (atau dipakai yang sudah ada), lalu janji
+// temu dibuat dengan semua pengecekan jadwal. Jika cfg.PatientUniqueKTPAndDOB
+// aktif, pasien lama hanya dipakai jika KTP dan tanggal lahirnya sama.
+// Semuanya dalam satu transaksi, sehingga jika janji temu gagal, pasien baru
+// juga tidak tersimpan.
+func WalkInHandler(dbpool *pgxpool.Pool, cfg *config.Config, ktpValidator KTPValidator) http.HandlerFunc {
+	if ktpValidator == nil {
+		ktpValidator = NumericKTPValidator{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// 1. Dekode & validasi data pasien
+		var req WalkInRequest
+		if err := decodeTolerantJSON(r.Body, &req); err != nil {
+			http.Error(w, decodeErrorMessage(err), http.StatusBadRequest)
+			return
+		}
+		dob, err := validatePatient(&req.Patient, ktpValidator)
+		if err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if err := validatePriority(&req.Priority); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		tx, err := dbpool.Begin(ctx)
+		if err != nil {
+			http.Error(w, "Gagal memulai transaksi", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		// 2. Pastikan dokternya ada sebelum pasien dibuat atau jadwal dicek,
+		// agar ID dokter yang salah dijawab 404 seperti booking lainnya
+		var doctorExists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM doctors WHERE id = $1)", req.DoctorID).Scan(&doctorExists); err != nil {
+			http.Error(w, "Gagal mengambil data dokter", http.StatusInternalServerError)
+			return
+		}
+		if !doctorExists {
+			http.Error(w, "Dokter dengan ID tersebut tidak ditemukan.", http.StatusNotFound)
+			return
+		}
+
+		// 3. Pakai pasien yang identitasnya sudah terdaftar, atau buat pasien baru
+		resp := WalkInResponse{}
+		p := &resp.Patient
+		findQuery := `SELECT ` + patientColumns + `
+                  FROM patients WHERE ktp_number = $1 AND (NOT $2 OR date_of_birth = $3)
+                  ORDER BY id
+                  LIMIT 1`
+		err = scanPatient(tx.QueryRow(ctx, findQuery, req.Patient.KTPNumber, cfg.PatientUniqueKTPAndDOB, dob), p)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Tanpa target ON CONFLICT agar berlaku untuk constraint KTP saja
+			// maupun KTP + tanggal lahir. Jika request lain baru saja membuat
+			// pasien yang sama, INSERT tidak menghasilkan baris dan pasien itu
+			// dicari ulang.
+			err = scanPatient(tx.QueryRow(ctx, `INSERT INTO patients (ktp_number, full_name, date_of_birth)
+                  VALUES ($1, $2, $3)
+                  ON CONFLICT DO NOTHING
+                  RETURNING `+patientColumns, req.Patient.KTPNumber, req.Patient.FullName, dob), p)
+			resp.PatientCreated = err == nil
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = scanPatient(tx.QueryRow(ctx, findQuery, req.Patient.KTPNumber, cfg.PatientUniqueKTPAndDOB, dob), p)
+			}
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			// KTP sudah dipakai pasien dengan tanggal lahir lain, tetapi database
+			// masih memakai constraint KTP saja (lihat README, Identitas pasien)
+			http.Error(w, uniqueConstraintMessages["patients_ktp_number_key"], http.StatusConflict)
+			return
+		}
+		if err != nil {
+			if msg, ok := uniqueViolationMessage(err); ok {
+				http.Error(w, msg, http.StatusConflict)
+				return
+			}
+			log.Printf("Gagal menyimpan pasien walk-in: %v", err)
+			http.Error(w, "Gagal menyimpan data pasien", http.StatusInternalServerError)
+			return
+		}
+
+		// 4. Validasi pasien dan jadwal janji temu di dalam transaksi yang sama
+		if !p.Active {
+			http.Error(w, "Pasien sudah dinonaktifkan dan tidak dapat membuat janji temu baru.", http.StatusConflict)
+			return
+		}
+		err = validateAppointmentSlot(ctx, tx, slotCheck{
+			DoctorID:  req.DoctorID,
+			PatientID: p.ID,
+			Date:      req.AppointmentDate,
+		}, cfg)
+		if err != nil {
+			writeSlotError(w, err)
+			return
+		}
+		if !isAdmin(r, cfg) {
+			if err := checkPatientDailyLimit(ctx, tx, p.ID, req.DoctorID, req.AppointmentDate, cfg); err != nil {
+				writeSlotError(w, err)
+				return
+			}
+		}
+		if err := expireStaleHolds(ctx, tx); err != nil {
+			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 5. Buat janji temu
+		query := `INSERT INTO appointments (patient_id, doctor_id, appointment_date, status, created_by, priority)
+                  VALUES ($1, $2, $3, $4, $5, $6)
+                  RETURNING ` + appointmentColumns
+		err = scanAppointment(tx.QueryRow(ctx, query, p.ID, req.DoctorID, req.AppointmentDate, cfg.DefaultAppointmentStatus, actorID(r), req.Priority), &resp.Appointment)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				switch pgErr.Code {
+				case "23505":
+					http.Error(w, constraintMessage(pgErr), http.StatusConflict)
+					return
+				case "23503":
+					http.Error(w, "Dokter dengan ID tersebut tidak ditemukan.", http.StatusNotFound)
+					return
+				}
+			}
+			log.Printf("Gagal menyimpan janji temu walk-in: %v", err)
+			http.Error(w, "Gagal menyimpan janji temu", http.StatusInternalServerError)
+			return
+		}
+
+		// 6. Commit: pasien dan janji temu tersimpan bersamaan
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Gagal commit pendaftaran walk-in: %v", err)
+			http.Error(w, "Gagal menyimpan pendaftaran walk-in", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage menyimpan isi file yang diunggah. Implementasi lain (mis. bucket
+// S3-compatible) cukup memenuhi interface ini tanpa mengubah handler.
+type Storage interface {
+	Save(key string, r io.Reader) error
+	Delete(key string) error
+}
+
+// LocalStorage menyimpan file di folder lokal.
+type LocalStorage struct {
+	Dir string
+}
+
+// NewLocalStorage membuat LocalStorage dan memastikan foldernya ada.
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{Dir: dir}, nil
+}
+
+// path mengubah key menjadi path di dalam Dir dan menolak key yang keluar dari Dir.
+func (s *LocalStorage) path(key string) (string, error) {
+	p := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, filepath.Clean(s.Dir)+string(filepath.Separator)) {
+		return "", errors.New("storage key tidak valid")
+	}
+	return p, nil
+}
+
+// Save menulis isi r ke file dengan nama key.
+func (s *LocalStorage) Save(key string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(p)
+		return err
+	}
+	return f.Close()
+}
+
+// Delete menghapus file dengan nama key.
+func (s *LocalStorage) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
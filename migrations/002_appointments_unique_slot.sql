@@ -0,0 +1,5 @@
+-- Mencegah dua janji temu aktif pada slot dokter yang sama.
+-- Janji temu yang dibatalkan tidak ikut dihitung agar slotnya bisa dipakai lagi.
+CREATE UNIQUE INDEX appointments_doctor_slot_unique
+    ON appointments (doctor_id, appointment_date)
+    WHERE status <> 'CANCELLED';
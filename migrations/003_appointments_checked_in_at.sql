@@ -0,0 +1,2 @@
+-- Mencatat waktu pasien check-in di meja depan
+ALTER TABLE appointments ADD COLUMN checked_in_at TIMESTAMPTZ;
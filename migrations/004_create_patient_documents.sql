@@ -0,0 +1,11 @@
+-- Membuat Tabel Dokumen Pasien (scan KTP, foto, dll).
+-- Isi file disimpan di storage terpisah, tabel ini hanya menyimpan metadata-nya.
+CREATE TABLE patient_documents (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
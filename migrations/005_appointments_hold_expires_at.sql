@@ -0,0 +1,2 @@
+-- Slot yang sedang di-hold (status HELD) hanya berlaku sampai waktu ini
+ALTER TABLE appointments ADD COLUMN hold_expires_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Mencatat kapan pengingat janji temu sudah dikirim ke pasien
+ALTER TABLE appointments ADD COLUMN reminder_sent_at TIMESTAMPTZ;
@@ -0,0 +1,8 @@
+-- Membuat Tabel Riwayat Perubahan Spesialisasi Dokter
+CREATE TABLE doctor_specialty_audit (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctors(id),
+    old_specialty VARCHAR(100) NOT NULL,
+    new_specialty VARCHAR(100) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
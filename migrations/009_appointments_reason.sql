@@ -0,0 +1,2 @@
+-- Menambahkan alasan kunjungan / catatan pada janji temu
+ALTER TABLE appointments ADD COLUMN reason VARCHAR(255);
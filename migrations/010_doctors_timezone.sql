@@ -0,0 +1,3 @@
+-- Zona waktu praktik dokter (nama IANA, mis. Asia/Makassar).
+-- NULL berarti memakai zona waktu aplikasi (APP_TIMEZONE).
+ALTER TABLE doctors ADD COLUMN timezone VARCHAR(64);
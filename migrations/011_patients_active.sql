@@ -0,0 +1,3 @@
+-- Pasien yang dinonaktifkan tetap tersimpan (beserta riwayat janji temunya)
+-- tetapi tidak bisa membuat janji temu baru.
+ALTER TABLE patients ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
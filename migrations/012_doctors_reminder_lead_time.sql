@@ -0,0 +1,3 @@
+-- Berapa lama sebelum janji temu pengingat dikirim untuk dokter ini.
+-- NULL berarti memakai REMINDER_LEAD_TIME aplikasi.
+ALTER TABLE doctors ADD COLUMN reminder_lead_time INTERVAL;
@@ -0,0 +1,5 @@
+-- Petugas/pengguna yang membuat janji temu (dari header X-Actor-ID), untuk audit.
+-- NULL untuk janji temu lama atau yang dibuat tanpa header tersebut.
+ALTER TABLE appointments ADD COLUMN created_by VARCHAR(64);
+
+CREATE INDEX appointments_created_by_idx ON appointments (created_by);
@@ -0,0 +1,6 @@
+-- Identitas pasien berdasarkan KTP + tanggal lahir (PATIENT_UNIQUE_KTP_DOB=true).
+-- Selama patients_ktp_number_key masih ada, constraint ini tidak mengubah
+-- apa pun karena KTP saja sudah unik. Constraint KTP saja dihapus oleh
+-- migrations/optional/patients_ktp_dob_identity.sql, yang hanya dijalankan
+-- oleh klinik yang mengaktifkan mode tersebut.
+ALTER TABLE patients ADD CONSTRAINT patients_ktp_number_dob_key UNIQUE (ktp_number, date_of_birth);
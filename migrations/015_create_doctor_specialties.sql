@@ -0,0 +1,12 @@
+-- Semua spesialisasi (termasuk subspesialisasi) seorang dokter.
+-- doctors.specialty tetap menjadi spesialisasi utama dan selalu ada di tabel ini.
+CREATE TABLE doctor_specialties (
+    doctor_id INT NOT NULL REFERENCES doctors(id) ON DELETE CASCADE,
+    specialty VARCHAR(100) NOT NULL,
+    PRIMARY KEY (doctor_id, specialty)
+);
+
+CREATE INDEX doctor_specialties_lower_specialty_idx ON doctor_specialties (LOWER(specialty));
+
+INSERT INTO doctor_specialties (doctor_id, specialty)
+SELECT id, specialty FROM doctors;
@@ -0,0 +1,4 @@
+-- NIK dokter boleh kosong untuk klinik yang memakai ID internal
+-- (DOCTOR_NIK_REQUIRED=false). UNIQUE tetap berlaku untuk NIK yang diisi;
+-- PostgreSQL tidak menganggap beberapa NULL sebagai duplikat.
+ALTER TABLE doctors ALTER COLUMN nik DROP NOT NULL;
@@ -0,0 +1,2 @@
+-- Mencatat kapan pasien mengonfirmasi kehadiran pada janji temu terjadwal
+ALTER TABLE appointments ADD COLUMN confirmed_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Catatan internal staf pada janji temu, terpisah dari reason yang diisi pasien.
+-- Tidak termasuk appointmentColumns sehingga tidak pernah ikut di response umum.
+ALTER TABLE appointments ADD COLUMN staff_notes TEXT;
@@ -0,0 +1,3 @@
+-- Tingkat urgensi janji temu untuk triase
+ALTER TABLE appointments ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT 'ROUTINE'
+    CHECK (priority IN ('ROUTINE', 'URGENT', 'EMERGENCY'));
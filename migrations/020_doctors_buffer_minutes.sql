@@ -0,0 +1,3 @@
+-- Jeda minimum (menit) antara akhir satu janji temu dokter dan awal janji
+-- temu berikutnya. 0 berarti janji temu boleh berurutan tanpa jeda.
+ALTER TABLE doctors ADD COLUMN buffer_minutes INTEGER NOT NULL DEFAULT 0 CHECK (buffer_minutes >= 0);
@@ -0,0 +1,10 @@
+-- Mengaktifkan identitas pasien berdasarkan KTP + tanggal lahir
+-- (PATIENT_UNIQUE_KTP_DOB=true). Tidak ikut dijalankan oleh `npm run migrate`;
+-- jalankan manual setelah 014_patients_ktp_dob_unique.sql, sebelum aplikasi
+-- dimulai dengan mode tersebut. Setelah ini beberapa pasien boleh memakai KTP
+-- yang sama selama tanggal lahirnya berbeda.
+--
+-- Untuk kembali ke mode KTP saja (pastikan tidak ada KTP ganda dulu):
+--
+--   ALTER TABLE patients ADD CONSTRAINT patients_ktp_number_key UNIQUE (ktp_number);
+ALTER TABLE patients DROP CONSTRAINT IF EXISTS patients_ktp_number_key;